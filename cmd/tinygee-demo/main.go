@@ -1,13 +1,16 @@
 package main
 
 import (
-	"expvar"
+	"context"
 	"flag"
 	"log"
 	"net/http"
+	"os/signal"
+	"syscall"
 
 	"github.com/xrjjing/Learn4Go/tinygee"
 	"github.com/xrjjing/Learn4Go/tinygee/middleware"
+	"github.com/xrjjing/Learn4Go/tinygee/middleware/metrics"
 )
 
 // Demo 入口：可选开启 /metrics。
@@ -27,14 +30,18 @@ func main() {
 	})
 
 	if *enableProm {
-		// 使用标准库 expvar 提供基础指标
-		r.GET("/metrics", func(c *tinygee.Context) {
-			expvar.Handler().ServeHTTP(c.Writer, c.Req)
-		})
+		// Prometheus 文本格式：按路由模式统计请求数、在途数与延迟，附带 Go 运行时指标
+		r.Use(metrics.New(metrics.Config{SkipPaths: []string{"/metrics"}}))
+		r.GET("/metrics", metrics.Default.Handler())
 	}
 
+	// 收到 SIGINT/SIGTERM 后等待在途请求完成再退出
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	r.OnShutdown(func() { log.Println("TinyGee demo stopped") })
+
 	log.Printf("TinyGee demo on %s (prometheus: %v)", *port, *enableProm)
-	if err := r.Run(*port); err != nil {
+	if err := r.RunContext(ctx, *port); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -12,15 +12,19 @@ package main
 // 排查后端启动失败、环境变量不生效、存储切换异常时，优先从本文件进入。
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/xrjjing/Learn4Go/internal/todo"
+	"github.com/xrjjing/Learn4Go/tinygee/middleware/tracing"
 )
 
 // TODO API 服务入口
@@ -29,6 +33,9 @@ import (
 //
 //	TODO_STORAGE:  存储类型 (memory | sqlite | mysql)，默认 memory
 //	TODO_ADDR:     监听地址，默认 :8080
+//	TRACE_EXPORTER: 链路追踪导出方式 (stdout | file | otlp)，详见 tracing.NewTracerFromEnv
+//	CORS_ALLOW_ORIGINS: 允许跨域的前端来源，逗号分隔，支持 https://*.example.com 通配；
+//	                默认只放行本机任意端口
 //
 // SQLite 配置:
 //
@@ -108,12 +115,23 @@ func main() {
 
 	// 第四步：创建业务 Server。
 	// todo.NewServer 内部会注册路由、准备用户存储、刷新令牌表以及清理协程。
-	s := todo.NewServer(store, todo.WithJWT(jwtSecret, 24*time.Hour))
+	opts := []todo.Option{todo.WithJWT(jwtSecret, 24*time.Hour)}
+	if origins := getEnv("CORS_ALLOW_ORIGINS", ""); origins != "" {
+		cors := todo.DefaultCORS
+		cors.AllowOrigins = strings.Split(origins, ",")
+		opts = append(opts, todo.WithCORS(cors))
+	}
+	s := todo.NewServer(store, opts...)
 
 	// 第五步：把业务 Handler 挂到标准库 HTTP Server 上。
+	// 外层包一层链路追踪，网关转发来的 traceparent 会被沿用，便于跨服务关联请求。
+	tracer, err := tracing.NewTracerFromEnv("todoapi")
+	if err != nil {
+		log.Fatalf("链路追踪配置错误: %v", err)
+	}
 	srv := &http.Server{
 		Addr:         addr,
-		Handler:      s.Handler(),
+		Handler:      tracing.Handler(tracer, s.Handler()),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -122,15 +140,8 @@ func main() {
 	// 第六步：异步启动 HTTP 服务，主 goroutine 留给优雅关闭流程使用。
 	go func() {
 		log.Printf("TODO API 启动: http://localhost%s", addr)
-		log.Println("API 端点 (v1):")
-		log.Println("  POST   /v1/register    - 注册")
-		log.Println("  POST   /v1/login       - 登录")
-		log.Println("  POST   /v1/refresh     - 刷新令牌")
-		log.Println("  GET    /v1/todos       - 列表")
-		log.Println("  POST   /v1/todos       - 创建")
-		log.Println("  PUT    /v1/todos/{id}  - 更新状态")
-		log.Println("  DELETE /v1/todos/{id}  - 删除")
-		log.Println("  GET    /healthz        - 健康检查")
+		log.Println("API 端点:")
+		printEndpoints(s.Endpoints())
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("服务启动失败: %v", err)
 		}
@@ -153,6 +164,9 @@ func main() {
 
 	// 再关闭 Server 自己维护的后台资源，比如 refresh/loginFailure 清理协程。
 	s.Shutdown()
+	if err := tracer.Shutdown(ctx); err != nil {
+		log.Printf("链路追踪导出错误: %v", err)
+	}
 
 	// 关闭数据库连接
 	if closer, ok := store.(interface{ Close() error }); ok {
@@ -164,6 +178,19 @@ func main() {
 	log.Println("服务已安全关闭")
 }
 
+// printEndpoints 打印 routes() 中登记的端点，避免手工维护的列表与实际路由脱节。
+func printEndpoints(endpoints []todo.Endpoint) {
+	var buf strings.Builder
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for _, ep := range endpoints {
+		fmt.Fprintf(tw, "  %s\t%s\t- %s\n", strings.Join(ep.Methods, ","), ep.Path, ep.Summary)
+	}
+	_ = tw.Flush()
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		log.Println(line)
+	}
+}
+
 // getEnv 是本文件的最小配置读取辅助函数。
 // 当排查环境变量“看起来没生效”时，可以先确认变量名和默认值是否走到了这里。
 func getEnv(key, defaultVal string) string {
@@ -38,6 +38,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xrjjing/Learn4Go/tinygee/middleware"
+	"github.com/xrjjing/Learn4Go/tinygee/middleware/tracing"
 )
 
 // BackendConfig 描述单个后端服务的名称、目标地址和超时。
@@ -116,18 +118,25 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
-// CORSMiddleware 跨域中间件
+// corsConfig 列出允许访问网关的前端来源。
+// 携带 Cookie/Authorization 的请求不接受 Allow-Origin: *，因此按白名单回显具体来源。
+var corsConfig = middleware.CORSConfig{
+	AllowOrigins:     []string{"http://localhost:*", "http://127.0.0.1:*", "https://*.example.com"},
+	AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+	AllowHeaders:     []string{"Content-Type", "Authorization"},
+	ExposeHeaders:    []string{"Retry-After"},
+	AllowCredentials: true,
+	MaxAge:           10 * time.Minute,
+}
+
+// CORSMiddleware 跨域中间件，复用 tinygee 的 CORS 规则；预检请求以 204 结束
 func CORSMiddleware() gin.HandlerFunc {
+	cors := middleware.NewCORSPolicy(corsConfig)
 	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusOK)
+		if cors.Apply(c.Writer.Header(), c.Request) {
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
-
 		c.Next()
 	}
 }
@@ -176,6 +185,8 @@ func createReverseProxy(target string) gin.HandlerFunc {
 	}
 
 	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+	// 转发时创建 client span 并注入 traceparent，后端日志可按同一 trace id 关联
+	proxy.Transport = tracing.Transport(nil)
 
 	// Director 会在请求真正发到后端前改写 URL、Host 和透传头。
 	originalDirector := proxy.Director
@@ -202,6 +213,19 @@ func createReverseProxy(target string) gin.HandlerFunc {
 	}
 }
 
+// TraceRouteMiddleware 用 Gin 的路由模式重命名 tracing.Handler 创建的 server span，
+// 避免按原始路径命名造成高基数。
+func TraceRouteMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if route := c.FullPath(); route != "" {
+			span := tracing.SpanFromContext(c.Request.Context())
+			span.SetName(c.Request.Method + " " + route)
+			span.SetAttr("http.route", route)
+		}
+		c.Next()
+	}
+}
+
 // setupRouter 负责组装网关对外暴露的全部路由和中间件链。
 // setupRouter：拼装根路由、健康检查和 /api 分组下的反向代理规则。
 func setupRouter() *gin.Engine {
@@ -214,6 +238,7 @@ func setupRouter() *gin.Engine {
 	r.Use(gin.Recovery()) // 恢复 panic
 	r.Use(CORSMiddleware())
 	r.Use(LoggerMiddleware())
+	r.Use(TraceRouteMiddleware())
 
 	// 根路径说明，避免裸访问 404
 	r.GET("/", func(c *gin.Context) {
@@ -259,10 +284,14 @@ func setupRouter() *gin.Engine {
 func main() {
 	r := setupRouter()
 
+	tracer, err := tracing.NewTracerFromEnv("gateway-gin")
+	if err != nil {
+		log.Fatalf("链路追踪配置错误: %v", err)
+	}
 	addr := getEnv("GATEWAY_ADDR", ":8888")
 	server := &http.Server{
 		Addr:         addr,
-		Handler:      r,
+		Handler:      tracing.Handler(tracer, r),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
@@ -27,6 +27,9 @@ import (
 	"net/url"
 	"strings"
 	"time"
+
+	tgmw "github.com/xrjjing/Learn4Go/tinygee/middleware"
+	"github.com/xrjjing/Learn4Go/tinygee/middleware/tracing"
 )
 
 // backends 是路径前缀到后端地址的映射表。
@@ -113,23 +116,21 @@ func authMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// corsConfig 列出允许访问网关的前端来源。
+// 携带 Cookie/Authorization 的请求不接受 Allow-Origin: *，因此按白名单回显具体来源。
+var corsConfig = tgmw.CORSConfig{
+	AllowOrigins:     []string{"http://localhost:*", "http://127.0.0.1:*", "https://*.example.com"},
+	AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+	AllowHeaders:     []string{"Content-Type", "Authorization"},
+	ExposeHeaders:    []string{"Retry-After"},
+	AllowCredentials: true,
+	MaxAge:           10 * time.Minute,
+}
+
 // corsMiddleware 跨域资源共享中间件
-// 允许前端从不同域名访问 API
+// 允许白名单中的前端跨域访问 API，预检请求直接以 204 返回
 func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// 设置 CORS 响应头
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		// 处理预检请求
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
+	return tgmw.CORSHandler(corsConfig, next)
 }
 
 // createProxy 返回真正负责转发请求的 handler。
@@ -141,8 +142,9 @@ func createProxy(target string) http.Handler {
 		log.Fatalf("解析后端地址失败: %v", err)
 	}
 
-	// 创建反向代理
+	// 创建反向代理；Transport 为每次转发创建 client span，并把 traceparent 带给后端
 	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+	proxy.Transport = tracing.Transport(nil)
 
 	// 自定义 Director 函数，可以修改转发的请求
 	originalDirector := proxy.Director
@@ -199,12 +201,16 @@ func router() http.Handler {
 // main：把中间件和路由拼起来后启动标准库网关。
 func main() {
 	// 组装中间件链
-	// 执行顺序: cors -> logging -> auth -> router
-	handler := chain(
+	// 执行顺序: tracing -> cors -> logging -> auth -> router
+	tracer, err := tracing.NewTracerFromEnv("gateway-stdlib")
+	if err != nil {
+		log.Fatalf("链路追踪配置错误: %v", err)
+	}
+	handler := tracing.Handler(tracer, chain(
 		corsMiddleware,
 		loggingMiddleware,
 		authMiddleware,
-	)(router())
+	)(router()))
 
 	// 配置服务器
 	server := &http.Server{
@@ -1,15 +1,21 @@
 package main
 
 import (
+	"embed"
 	"html/template"
+	"io/fs"
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/xrjjing/Learn4Go/tinygee"
 	"github.com/xrjjing/Learn4Go/tinygee/middleware"
 	"github.com/xrjjing/Learn4Go/tinygee/render"
 )
 
+//go:embed templates
+var templateFS embed.FS
+
 func main() {
 	r := tinygee.New()
 	r.Use(middleware.Logger(), middleware.Recover())
@@ -17,11 +23,22 @@ func main() {
 	funcMap := template.FuncMap{
 		"upper": func(s string) string { return template.HTMLEscapeString(s) },
 	}
-	renderer, err := render.New("examples/tinygee/day6/templates/*.html", funcMap)
+	// 默认使用编译进二进制的模板；调试模式下直接读源码目录并在修改后自动重新解析
+	templates, err := fs.Sub(templateFS, "templates")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if r.DebugMode {
+		templates = os.DirFS("examples/tinygee/day6/templates")
+	}
+	renderer, err := render.NewFS(templates, render.WithFuncs(funcMap), render.WithDevMode(r.DebugMode))
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	r.GET("/", func(c *tinygee.Context) {
+		renderer.HTML(c, http.StatusOK, "index.html", map[string]any{"Name": "TinyGee"})
+	})
 	r.GET("/hello", func(c *tinygee.Context) {
 		renderer.HTML(c, http.StatusOK, "hello.html", map[string]any{"Name": "TinyGee"})
 	})
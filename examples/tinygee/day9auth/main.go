@@ -34,6 +34,14 @@ func main() {
 		c.JSON(http.StatusOK, map[string]string{"message": "pong"})
 	})
 
+	// 路由级中间件：只有这个接口额外要求 admin 可访问 /api/admin 前缀
+	adminOnly := auth.RBAC(auth.RBACConfig{
+		RolePermissions: map[string][]string{"admin": {"/api/admin"}},
+	})
+	api.DELETE("/admin/cache", adminOnly, func(c *tinygee.Context) {
+		c.JSON(http.StatusOK, map[string]string{"message": "cache cleared"})
+	})
+
 	log.Printf("use token: %s", token)
 	log.Println("tinygee auth demo on :8089")
 	log.Fatal(app.Run(":8089"))
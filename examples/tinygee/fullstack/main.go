@@ -1,15 +1,18 @@
 package main
 
 import (
-	"expvar"
+	"context"
 	"html/template"
 	"log"
 	"net/http"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/xrjjing/Learn4Go/tinygee"
 	"github.com/xrjjing/Learn4Go/tinygee/middleware"
 	"github.com/xrjjing/Learn4Go/tinygee/middleware/auth"
+	"github.com/xrjjing/Learn4Go/tinygee/middleware/metrics"
 	"github.com/xrjjing/Learn4Go/tinygee/middleware/ratelimit"
 	"github.com/xrjjing/Learn4Go/tinygee/render"
 )
@@ -25,7 +28,10 @@ func main() {
 	log.Printf("示例 token: %s", token)
 
 	r := tinygee.New()
-	r.Use(middleware.Logger(), middleware.Recover())
+	// 结构化访问日志，带请求 ID；/metrics 抓取频繁，不记录
+	r.Use(middleware.RequestID(), middleware.AccessLogger(middleware.AccessLogConfig{
+		SkipPaths: []string{"/metrics"},
+	}), middleware.Recover())
 
 	// 限流（每秒 5 次）
 	r.Use(ratelimit.New(time.Second, 5).Middleware())
@@ -50,15 +56,29 @@ func main() {
 			"user":  {"/api/public"},
 		},
 	}))
+	// 普通接口 2 秒超时，报表接口在路由上放宽到 30 秒
+	api.Use(middleware.Timeout(2 * time.Second))
 	api.GET("/secure", func(c *tinygee.Context) {
 		c.JSON(http.StatusOK, map[string]string{"message": "secure ok"})
 	})
-
-	// metrics
-	r.GET("/metrics", func(c *tinygee.Context) {
-		expvar.Handler().ServeHTTP(c.Writer, c.Req)
+	api.GET("/report", middleware.Timeout(30*time.Second), func(c *tinygee.Context) {
+		select {
+		case <-time.After(3 * time.Second): // 模拟慢查询
+			c.JSON(http.StatusOK, map[string]string{"report": "ready"})
+		case <-c.Req.Context().Done():
+		}
 	})
 
+	// Prometheus 指标
+	r.Use(metrics.New(metrics.Config{SkipPaths: []string{"/metrics"}}))
+	r.GET("/metrics", metrics.Default.Handler())
+
+	// SIGTERM 时优雅退出：排空在途请求后停止限流器清理协程
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	log.Println("tinygee fullstack on :8092")
-	log.Fatal(r.Run(":8092"))
+	if err := r.RunContext(ctx, ":8092"); err != nil {
+		log.Fatal(err)
+	}
 }
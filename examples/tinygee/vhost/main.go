@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+
+	"github.com/xrjjing/Learn4Go/internal/todo"
+	"github.com/xrjjing/Learn4Go/tinygee"
+	"github.com/xrjjing/Learn4Go/tinygee/middleware"
+	"github.com/xrjjing/Learn4Go/tinygee/render"
+	"github.com/xrjjing/Learn4Go/web"
+)
+
+// 虚拟主机示例：同一进程按 Host 头分别提供 TODO API 与管理后台静态页面。
+//
+//	curl -H 'Host: api.localhost' localhost:8093/healthz
+//	curl -H 'Host: admin.localhost' localhost:8093/admin.html
+func main() {
+	r := tinygee.New()
+	r.Use(middleware.Logger(), middleware.Recover())
+
+	// api.localhost：整体挂载基于 net/http 的 TODO API
+	todoServer := todo.NewServer(todo.NewStore())
+	r.OnShutdown(todoServer.Shutdown)
+	// 列表接口返回的 JSON 较大，按 Accept-Encoding 压缩
+	api := r.Host("api.localhost")
+	api.Use(middleware.Compress())
+	api.Mount("/", todoServer.Handler())
+
+	// admin.localhost：管理后台前端页面，已编译进二进制；html 每次校验，其余资源缓存一小时
+	admin := r.Host("admin.localhost")
+	assets := render.StaticFS(render.StaticConfig{
+		Root: web.Files,
+		CacheControl: map[string]string{
+			".html": "no-cache",
+			"":      "public, max-age=3600",
+		},
+	})
+	admin.GET("/", assets)
+	admin.GET("/*filepath", assets)
+
+	// 其他 Host 走默认路由
+	r.GET("/", func(c *tinygee.Context) {
+		c.String(http.StatusOK, "use Host api.localhost or admin.localhost")
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log.Println("tinygee vhost demo on :8093")
+	if err := r.RunContext(ctx, ":8093"); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -19,10 +19,13 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/xrjjing/Learn4Go/tinygee/middleware"
 )
 
 // slogger 用于记录结构化 HTTP 访问日志，便于在本地和容器环境中统一检索。
@@ -44,7 +47,9 @@ type Server struct {
 	jwtManager  *JWTManager
 	rateLimiter *RateLimiter
 	rbacManager *RBACManager
+	cors        middleware.CORSConfig
 	mux         *http.ServeMux
+	endpoints   []Endpoint
 	// 登录安全与 refresh token 状态。
 	// 这部分数据当前都在内存里，适合教学演示，但不适合多实例共享。
 	refreshTTL    time.Duration
@@ -82,6 +87,24 @@ func WithRateLimiter(rl *RateLimiter) Option {
 	}
 }
 
+// WithCORS 替换默认的跨域配置，生产环境应列出前端的实际来源。
+func WithCORS(cfg middleware.CORSConfig) Option {
+	return func(s *Server) {
+		s.cors = cfg
+	}
+}
+
+// DefaultCORS 为本地开发放行任意端口的 localhost 前端（如 8000 端口的页面直连 8080 上的 API）。
+// Docker 部署下由 Nginx 处理 CORS，这里主要覆盖直连 API 的场景。
+var DefaultCORS = middleware.CORSConfig{
+	AllowOrigins: []string{"http://localhost:*", "http://127.0.0.1:*"},
+	AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch},
+	AllowHeaders: []string{"Content-Type", "Authorization"},
+	// 暴露登录限流等场景使用到的 Retry-After 头，便于前端读取
+	ExposeHeaders: []string{"Retry-After"},
+	MaxAge:        10 * time.Minute,
+}
+
 // NewServer 是业务层的装配入口。
 //
 // main.go 在启动阶段调用它；页面请求最终也都会经过它返回的 Handler。
@@ -93,6 +116,7 @@ func NewServer(store TodoStore, opts ...Option) *Server {
 		userStore:     NewMemoryUserStore(), // 默认内存用户存储，便于测试
 		jwtManager:    NewJWTManager("dev-secret-change-me-in-production", 24*time.Hour),
 		rbacManager:   NewRBACManager(),
+		cors:          DefaultCORS,
 		mux:           http.NewServeMux(),
 		refreshTTL:    defaultRefreshTTL,
 		refreshStore:  make(map[string]refreshSession),
@@ -184,7 +208,7 @@ func (s *Server) Handler() http.Handler {
 		h = s.rateLimiter.Middleware(h)
 	}
 	h = loggingMiddleware(h)
-	h = corsMiddleware(h)
+	h = middleware.CORSHandler(s.cors, h)
 	return h
 }
 
@@ -194,19 +218,19 @@ func (s *Server) Handler() http.Handler {
 // routes：定义所有 HTTP 入口，并把不同 URL 映射到对应业务处理函数。
 func (s *Server) routes() {
 	// 根路径返回服务说明，避免裸访问 404 误判
-	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/" {
-			http.NotFound(w, r)
-			return
+	s.handleFunc("/", "服务说明", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) {
+		paths := make([]string, 0, len(s.endpoints))
+		for _, ep := range s.endpoints {
+			paths = append(paths, ep.Path)
 		}
 		respondJSON(w, map[string]any{
 			"service":   "Learn4Go TODO API",
 			"version":   "1.0",
-			"endpoints": []string{"/v1/todos", "/v1/todos/{id}", "/healthz"},
+			"endpoints": paths,
 		}, http.StatusOK)
 	})
 
-	s.mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+	s.handleFunc("/healthz", "健康检查", []string{http.MethodGet}, func(w http.ResponseWriter, r *http.Request) {
 		status := map[string]any{
 			"status": "ok",
 			"checks": map[string]string{},
@@ -234,23 +258,23 @@ func (s *Server) routes() {
 
 	// 认证主链路：注册 / 登录 / refresh。
 	// 对前端登录页和 auth-helper.js 来说，这一组是最核心的后端入口。
-	s.mux.HandleFunc("/v1/register", s.handleRegister)
-	s.mux.HandleFunc("/v1/login", s.handleLogin)
-	s.mux.HandleFunc("/v1/refresh", s.handleRefresh)
+	s.handleFunc("/v1/register", "注册", []string{http.MethodPost}, s.handleRegister)
+	s.handleFunc("/v1/login", "登录", []string{http.MethodPost}, s.handleLogin)
+	s.handleFunc("/v1/refresh", "刷新令牌", []string{http.MethodPost}, s.handleRefresh)
 
 	// 管理后台相关接口：当前用户、退出登录、用户管理、角色和权限列表。
-	s.mux.Handle("/v1/me", s.authMiddleware(http.HandlerFunc(s.handleGetCurrentUser)))
-	s.mux.Handle("/v1/logout", s.authMiddleware(http.HandlerFunc(s.handleLogout)))
-	s.mux.Handle("/v1/users", s.authMiddleware(http.HandlerFunc(s.handleUsers)))
-	s.mux.Handle("/v1/users/", s.authMiddleware(http.HandlerFunc(s.handleUserDetail)))
-	s.mux.Handle("/v1/rbac/roles", s.authMiddleware(http.HandlerFunc(s.handleRoles)))
-	s.mux.Handle("/v1/rbac/permissions", s.authMiddleware(http.HandlerFunc(s.handlePermissions)))
+	s.handle("/v1/me", "当前用户", []string{http.MethodGet}, s.authMiddleware(http.HandlerFunc(s.handleGetCurrentUser)))
+	s.handle("/v1/logout", "退出登录", []string{http.MethodPost}, s.authMiddleware(http.HandlerFunc(s.handleLogout)))
+	s.handle("/v1/users", "用户列表 / 创建用户", []string{http.MethodGet, http.MethodPost}, s.authMiddleware(http.HandlerFunc(s.handleUsers)))
+	s.handle("/v1/users/{id}", "更新用户", []string{http.MethodPatch}, s.authMiddleware(http.HandlerFunc(s.handleUserDetail)))
+	s.handle("/v1/rbac/roles", "角色列表 / 创建角色", []string{http.MethodGet, http.MethodPost}, s.authMiddleware(http.HandlerFunc(s.handleRoles)))
+	s.handle("/v1/rbac/permissions", "权限列表", []string{http.MethodGet}, s.authMiddleware(http.HandlerFunc(s.handlePermissions)))
 
 	// TODO 集合资源：
 	// - GET 负责列表
 	// - POST 负责创建
 	// 鉴权与 RBAC 已经在 Handler() 中间件链里处理过，这里主要做业务分发。
-	s.mux.HandleFunc("/v1/todos", func(w http.ResponseWriter, r *http.Request) {
+	s.handleFunc("/v1/todos", "TODO 列表 / 创建", []string{http.MethodGet, http.MethodPost}, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			// 根据用户角色控制可见范围
@@ -322,7 +346,7 @@ func (s *Server) routes() {
 	// - PUT 更新完成状态
 	// - DELETE 删除
 	// 路径里的 id 会先在这里解析，再调用存储层。
-	s.mux.HandleFunc("/v1/todos/", func(w http.ResponseWriter, r *http.Request) {
+	s.handleFunc("/v1/todos/{id}", "更新状态 / 删除 TODO", []string{http.MethodPut, http.MethodDelete}, func(w http.ResponseWriter, r *http.Request) {
 		idStr := r.URL.Path[len("/v1/todos/"):]
 		id, err := strconv.Atoi(idStr)
 		if err != nil {
@@ -365,6 +389,42 @@ func (s *Server) routes() {
 	})
 }
 
+// Endpoint 描述一个对外接口，由 handle 在注册路由时生成，启动日志与根路径据此列出端点。
+type Endpoint struct {
+	Methods []string
+	Path    string
+	Summary string
+}
+
+// Endpoints 按注册顺序返回全部接口。
+func (s *Server) Endpoints() []Endpoint {
+	return append([]Endpoint(nil), s.endpoints...)
+}
+
+// handle 是注册路由的唯一入口：同一份 path、methods 既注册到 ServeMux，也登记为端点，
+// 因此端点表不会与实际路由脱节。不在 methods 中的请求直接返回 405，不会进入 h。
+// path 按 ServeMux 模式注册，"/" 只匹配根路径本身。
+func (s *Server) handle(path, summary string, methods []string, h http.Handler) {
+	pattern := path
+	if path == "/" {
+		pattern = "/{$}"
+	}
+	allow := strings.Join(methods, ", ")
+	s.mux.Handle(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(methods, r.Method) {
+			w.Header().Set("Allow", allow)
+			respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h.ServeHTTP(w, r)
+	}))
+	s.endpoints = append(s.endpoints, Endpoint{Methods: methods, Path: path, Summary: summary})
+}
+
+func (s *Server) handleFunc(path, summary string, methods []string, h http.HandlerFunc) {
+	s.handle(path, summary, methods, h)
+}
+
 // respondJSON / respondError 是最底层的响应辅助函数。
 // 当你只想确认“后端最终返回了什么 JSON”，可以直接从这里打日志或下断点。
 func respondJSON(w http.ResponseWriter, v any, code int) {
@@ -902,22 +962,3 @@ func loggingMiddleware(next http.Handler) http.Handler {
 		)
 	})
 }
-
-// corsMiddleware 为本地开发提供简单的 CORS 支持，方便从 8000 端口的前端页面访问 8080 上的 TODO API。
-// Docker 部署下由 Nginx 处理 CORS，这里主要覆盖直连 API 的场景。
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		// 暴露登录限流等场景使用到的 Retry-After 头，便于前端读取
-		w.Header().Set("Access-Control-Expose-Headers", "Retry-After")
-
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
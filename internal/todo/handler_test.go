@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -80,3 +82,75 @@ func TestTodoFlow(t *testing.T) {
 		t.Fatalf("delete code %d", rr.Code)
 	}
 }
+
+func TestEndpointsMatchRoutes(t *testing.T) {
+	s := NewServer(NewStore())
+	defer s.Shutdown()
+
+	eps := s.Endpoints()
+	if len(eps) == 0 {
+		t.Fatal("no endpoints registered")
+	}
+	all := []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	for _, ep := range eps {
+		if len(ep.Methods) == 0 || ep.Summary == "" {
+			t.Fatalf("incomplete endpoint %+v", ep)
+		}
+		path := strings.ReplaceAll(ep.Path, "{id}", "1")
+		for _, m := range all {
+			// 登记的方法都由该端点的路由处理；其余方法在进入 handler 之前就被拒绝
+			_, pattern := s.mux.Handler(httptest.NewRequest(m, path, nil))
+			if pattern != ep.Path && !(ep.Path == "/" && pattern == "/{$}") {
+				t.Fatalf("%s %s is routed to %q", m, ep.Path, pattern)
+			}
+			if slices.Contains(ep.Methods, m) {
+				continue
+			}
+			rr := httptest.NewRecorder()
+			s.mux.ServeHTTP(rr, httptest.NewRequest(m, path, nil))
+			if rr.Code != http.StatusMethodNotAllowed || rr.Header().Get("Allow") != strings.Join(ep.Methods, ", ") {
+				t.Fatalf("%s %s: got %d Allow=%q", m, ep.Path, rr.Code, rr.Header().Get("Allow"))
+			}
+		}
+	}
+
+	// 未登记的路径不会被任何路由接住
+	if _, pattern := s.mux.Handler(httptest.NewRequest(http.MethodGet, "/v1/unknown", nil)); pattern != "" {
+		t.Fatalf("unregistered path routed to %q", pattern)
+	}
+
+	// 根路径返回的端点列表同样来自登记结果
+	rr := httptest.NewRecorder()
+	s.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	var info struct {
+		Endpoints []string `json:"endpoints"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&info); err != nil || len(info.Endpoints) != len(eps) {
+		t.Fatalf("root endpoints %v, err %v", info.Endpoints, err)
+	}
+}
+
+func TestCORSAllowlist(t *testing.T) {
+	s := NewServer(NewStore())
+	defer s.Shutdown()
+	handler := s.Handler()
+
+	// 本机前端的预检在鉴权之前就以 204 结束
+	req := httptest.NewRequest(http.MethodOptions, "/v1/todos", nil)
+	req.Header.Set("Origin", "http://localhost:8000")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNoContent || rr.Header().Get("Access-Control-Allow-Origin") != "http://localhost:8000" {
+		t.Fatalf("preflight: %d %v", rr.Code, rr.Header())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "" || rr.Header().Get("Vary") != "Origin" {
+		t.Fatalf("unexpected CORS headers for foreign origin: %v", rr.Header())
+	}
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/xrjjing/Learn4Go/rabbitmq-demo/internal/logstore"
 	"github.com/xrjjing/Learn4Go/rabbitmq-demo/internal/rabbit"
+	"github.com/xrjjing/Learn4Go/tinygee/middleware/tracing"
 )
 
 // AppConfig 汇总运行所需配置。
@@ -27,6 +28,8 @@ type AppConfig struct {
 	MockPath    string
 	Mode        string
 	LogCapacity int
+	// Tracer 为 nil 时只传播 traceparent 不导出
+	Tracer *tracing.Tracer
 }
 
 func loadConfig() AppConfig {
@@ -128,6 +131,14 @@ func main() {
 
 	logStore := logstore.New(cfg.LogCapacity)
 
+	// TRACE_EXPORTER=stdout|file|otlp 开启链路追踪，发布与消费归入同一条链路
+	tracer, err := tracing.NewTracerFromEnv("rabbitmq-demo")
+	if err != nil {
+		log.Fatalf("初始化链路追踪失败: %v", err)
+	}
+	defer tracer.Shutdown(context.Background())
+	cfg.Tracer = tracer
+
 	var mq rabbit.MQ
 	if os.Getenv("RABBITMQ_FAKE") == "1" {
 		mq = rabbit.NewMock(cfg.Rabbit)
@@ -150,7 +161,7 @@ func main() {
 		log.Fatalf("启动服务失败: %v", err)
 	}
 
-	srv := &http.Server{Addr: ":" + cfg.Port, Handler: mux}
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: tracing.Handler(tracer, mux)}
 
 	log.Printf("RabbitMQ Demo 服务启动，端口 %s，静态目录 %s", cfg.Port, cfg.StaticDir)
 	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -160,22 +171,27 @@ func main() {
 
 // setupHTTP 负责启动消费者并返回 HTTP mux，便于测试复用。
 func setupHTTP(ctx context.Context, cfg AppConfig, mq rabbit.MQ, mockMessages []rabbit.DemoMessage, logStore *logstore.Store) (*http.ServeMux, error) {
+	tracer := cfg.Tracer
+	if tracer == nil {
+		tracer = tracing.NewTracer(tracing.TracerConfig{})
+	}
+
 	// 启动工作队列消费者
-	if err := mq.Consume(ctx, cfg.Rabbit.WorkQueue, func(msg rabbit.DemoMessage) error {
-		logStore.Add(logstore.Entry{Time: time.Now(), Kind: "consume", ID: msg.ID, Type: msg.Type, Message: "工作队列消息已消费"})
+	if err := mq.Consume(ctx, cfg.Rabbit.WorkQueue, traced(tracer, cfg.Rabbit.WorkQueue, func(msg rabbit.DemoMessage, traceID string) error {
+		logStore.Add(logstore.Entry{Time: time.Now(), Kind: "consume", ID: msg.ID, Type: msg.Type, Message: "工作队列消息已消费", TraceID: traceID})
 		if msg.Type == "order.fail" {
 			return errors.New("模拟业务失败")
 		}
 		return nil
-	}); err != nil {
+	})); err != nil {
 		return nil, err
 	}
 
 	// 启动死信队列消费者
-	if err := mq.Consume(ctx, cfg.Rabbit.DLXQueue, func(msg rabbit.DemoMessage) error {
-		logStore.Add(logstore.Entry{Time: time.Now(), Kind: "dlx", ID: msg.ID, Type: msg.Type, Message: "死信队列收到消息"})
+	if err := mq.Consume(ctx, cfg.Rabbit.DLXQueue, traced(tracer, cfg.Rabbit.DLXQueue, func(msg rabbit.DemoMessage, traceID string) error {
+		logStore.Add(logstore.Entry{Time: time.Now(), Kind: "dlx", ID: msg.ID, Type: msg.Type, Message: "死信队列收到消息", TraceID: traceID})
 		return nil
-	}); err != nil {
+	})); err != nil {
 		return nil, err
 	}
 
@@ -231,11 +247,11 @@ func setupHTTP(ctx context.Context, cfg AppConfig, mq rabbit.MQ, mockMessages []
 			msg.Type = "order.created"
 		}
 		if err := mq.Publish(r.Context(), msg); err != nil {
-			logStore.Add(logstore.Entry{Time: time.Now(), Kind: "error", ID: msg.ID, Type: msg.Type, Message: err.Error()})
+			logStore.Add(logstore.Entry{Time: time.Now(), Kind: "error", ID: msg.ID, Type: msg.Type, Message: err.Error(), TraceID: traceIDFrom(r.Context())})
 			http.Error(w, "发布失败", http.StatusInternalServerError)
 			return
 		}
-		logStore.Add(logstore.Entry{Time: time.Now(), Kind: "send", ID: msg.ID, Type: msg.Type, Message: "消息已发布"})
+		logStore.Add(logstore.Entry{Time: time.Now(), Kind: "send", ID: msg.ID, Type: msg.Type, Message: "消息已发布", TraceID: traceIDFrom(r.Context())})
 		respondJSON(w, map[string]string{"id": msg.ID})
 	})
 
@@ -251,11 +267,11 @@ func setupHTTP(ctx context.Context, cfg AppConfig, mq rabbit.MQ, mockMessages []
 				localMsg.ID = uuid.NewString()
 			}
 			if err := mq.Publish(r.Context(), localMsg); err != nil {
-				logStore.Add(logstore.Entry{Time: time.Now(), Kind: "error", ID: localMsg.ID, Type: localMsg.Type, Message: err.Error()})
+				logStore.Add(logstore.Entry{Time: time.Now(), Kind: "error", ID: localMsg.ID, Type: localMsg.Type, Message: err.Error(), TraceID: traceIDFrom(r.Context())})
 				http.Error(w, "部分发布失败", http.StatusInternalServerError)
 				return
 			}
-			logStore.Add(logstore.Entry{Time: time.Now(), Kind: "send", ID: localMsg.ID, Type: localMsg.Type, Message: "批量消息已发布"})
+			logStore.Add(logstore.Entry{Time: time.Now(), Kind: "send", ID: localMsg.ID, Type: localMsg.Type, Message: "批量消息已发布", TraceID: traceIDFrom(r.Context())})
 			count++
 		}
 		respondJSON(w, map[string]int{"published": count})
@@ -267,6 +283,33 @@ func setupHTTP(ctx context.Context, cfg AppConfig, mq rabbit.MQ, mockMessages []
 	return mux, nil
 }
 
+// traced 为每条消息创建 consumer span，父 span 取自消息携带的 traceparent，
+// 处理函数拿到 trace id 写入日志，便于与发布请求对照。
+func traced(t *tracing.Tracer, queue string, handle func(msg rabbit.DemoMessage, traceID string) error) func(rabbit.DemoMessage) error {
+	return func(msg rabbit.DemoMessage) error {
+		ctx := context.Background()
+		if parent, err := tracing.ParseTraceparent(msg.Traceparent); err == nil {
+			ctx = tracing.ContextWithRemoteSpanContext(ctx, parent)
+		}
+		ctx, span := t.Start(ctx, "consume "+queue, tracing.WithKind(tracing.KindConsumer), tracing.WithAttrs(
+			tracing.Attr{Key: "messaging.destination.name", Value: queue},
+			tracing.Attr{Key: "messaging.message.id", Value: msg.ID},
+		))
+		defer span.End()
+		err := handle(msg, traceIDFrom(ctx))
+		span.RecordError(err)
+		return err
+	}
+}
+
+// traceIDFrom 返回 ctx 所在链路的 trace id，没有链路时为空。
+func traceIDFrom(ctx context.Context) string {
+	if sc := tracing.SpanContextFromContext(ctx); sc.IsValid() {
+		return sc.TraceID.String()
+	}
+	return ""
+}
+
 func respondJSON(w http.ResponseWriter, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	enc := json.NewEncoder(w)
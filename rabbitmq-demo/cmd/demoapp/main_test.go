@@ -11,6 +11,7 @@ import (
 
 	"github.com/xrjjing/Learn4Go/rabbitmq-demo/internal/logstore"
 	"github.com/xrjjing/Learn4Go/rabbitmq-demo/internal/rabbit"
+	"github.com/xrjjing/Learn4Go/tinygee/middleware/tracing"
 )
 
 func newTestMux(t *testing.T) (*http.ServeMux, *logstore.Store, context.CancelFunc) {
@@ -110,3 +111,29 @@ func TestTTLToDLX(t *testing.T) {
 		t.Fatalf("expect dlx log for ttl message")
 	}
 }
+
+func TestTraceFollowsMessage(t *testing.T) {
+	mux, store, _ := newTestMux(t)
+	h := tracing.Handler(tracing.NewTracer(tracing.TracerConfig{}), mux)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	body := []byte(`{"type":"order.created","payload":{"orderId":"TR"}}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/messages", bytes.NewReader(body))
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expect 200, got %d", rr.Code)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	kinds := map[string]bool{}
+	for _, l := range store.List() {
+		if l.TraceID == traceID {
+			kinds[l.Kind] = true
+		}
+	}
+	if !kinds["send"] || !kinds["consume"] {
+		t.Fatalf("expect send and consume logs in trace %s, got %v", traceID, store.List())
+	}
+}
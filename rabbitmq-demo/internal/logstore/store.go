@@ -12,6 +12,8 @@ type Entry struct {
 	ID      string    `json:"id"`
 	Type    string    `json:"type"`
 	Message string    `json:"message"`
+	// TraceID 关联发布请求与消费过程，未启用追踪时为空
+	TraceID string `json:"trace_id,omitempty"`
 }
 
 // Store 提供固定容量的线程安全日志存储。
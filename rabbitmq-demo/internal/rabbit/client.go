@@ -8,6 +8,7 @@ import (
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/xrjjing/Learn4Go/tinygee/middleware/tracing"
 )
 
 // Config 描述 RabbitMQ 拓扑与连接配置。
@@ -46,6 +47,8 @@ type DemoMessage struct {
 	Type    string          `json:"type"`
 	Payload json.RawMessage `json:"payload"`
 	TTLMS   int64           `json:"ttl_ms,omitempty"`
+	// Traceparent 是发布时所在链路的 W3C traceparent，由 Publish 自动填充
+	Traceparent string `json:"traceparent,omitempty"`
 }
 
 // Client 封装连接与常用操作。
@@ -145,6 +148,8 @@ func (c *Client) Publish(ctx context.Context, msg DemoMessage) error {
 	if msg.ID == "" {
 		return errors.New("消息缺少 id")
 	}
+	span := startPublish(ctx, &msg)
+	defer span.End()
 	body, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("序列化消息失败: %w", err)
@@ -158,12 +163,18 @@ func (c *Client) Publish(ctx context.Context, msg DemoMessage) error {
 		MessageId:    msg.ID,
 		Type:         msg.Type,
 	}
+	if msg.Traceparent != "" {
+		publish.Headers = amqp.Table{tracing.TraceparentHeader: msg.Traceparent}
+	}
 
+	exchange, key := c.conf.Exchange, msg.Type
 	if msg.TTLMS > 0 {
 		publish.Expiration = fmt.Sprintf("%d", msg.TTLMS)
-		return c.ch.PublishWithContext(ctx, c.conf.DelayExchange, c.conf.RoutingDLX, false, false, publish)
+		exchange, key = c.conf.DelayExchange, c.conf.RoutingDLX
 	}
-	return c.ch.PublishWithContext(ctx, c.conf.Exchange, msg.Type, false, false, publish)
+	err = c.ch.PublishWithContext(ctx, exchange, key, false, false, publish)
+	span.RecordError(err)
+	return err
 }
 
 // Consume 启动消费协程。
@@ -43,6 +43,8 @@ func (m *MockClient) DeclareTopology(ctx context.Context) error {
 }
 
 func (m *MockClient) Publish(ctx context.Context, msg DemoMessage) error {
+	span := startPublish(ctx, &msg)
+	defer span.End()
 	if msg.TTLMS > 0 {
 		// 延迟模拟：到期后进入 DLX 队列
 		go func() {
@@ -0,0 +1,18 @@
+package rabbit
+
+import (
+	"context"
+
+	"github.com/xrjjing/Learn4Go/tinygee/middleware/tracing"
+)
+
+// startPublish 以 ctx 中的 span 为父创建 producer span，并把 traceparent 写入消息，
+// 消费者据此把处理过程接到同一条链路上。ctx 中没有链路时返回 nil span（空操作）。
+func startPublish(ctx context.Context, msg *DemoMessage) *tracing.Span {
+	ctx, span := tracing.Start(ctx, "publish "+msg.Type, tracing.WithKind(tracing.KindProducer),
+		tracing.WithAttrs(tracing.Attr{Key: "messaging.message.id", Value: msg.ID}))
+	if sc := tracing.SpanContextFromContext(ctx); msg.Traceparent == "" && sc.IsValid() {
+		msg.Traceparent = sc.Traceparent()
+	}
+	return span
+}
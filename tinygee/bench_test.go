@@ -32,12 +32,35 @@ func BenchmarkRouterMatch(b *testing.B) {
 	}
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/item/42", nil)
 	w := httptest.NewRecorder()
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		engine.ServeHTTP(w, req)
 	}
 }
 
+// 只衡量路由树查找，不经过 Context 与响应写入；静态与参数路由都应为 0 allocs/op。
+func BenchmarkRouterLookup(b *testing.B) {
+	engine := New()
+	for i := 0; i < 100; i++ {
+		engine.GET("/api/v1/item/"+strconv.Itoa(i), func(c *Context) {})
+	}
+	engine.GET("/api/v1/users/:id/posts/:post", func(c *Context) {})
+
+	for name, path := range map[string]string{"static": "/api/v1/item/42", "param": "/api/v1/users/42/posts/7"} {
+		b.Run(name, func(b *testing.B) {
+			values := make([]string, 0, 4)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				values = values[:0]
+				if engine.router.find(http.MethodGet, path, &values) == nil {
+					b.Fatal("route not found")
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkRouterMatch_MapBaseline(b *testing.B) {
 	r := newMapRouter()
 	for i := 0; i < 100; i++ {
@@ -46,6 +69,7 @@ func BenchmarkRouterMatch_MapBaseline(b *testing.B) {
 	}
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/item/42", nil)
 	w := httptest.NewRecorder()
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		ctx := NewContext(w, req)
@@ -60,6 +84,7 @@ func BenchmarkMiddlewareChain(b *testing.B) {
 
 	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
 	w := httptest.NewRecorder()
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		engine.ServeHTTP(w, req)
@@ -0,0 +1,385 @@
+package tinygee
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validators 按绑定使用的标签各保存一个共享验证器，错误里的字段名与该绑定读取的参数名一致，
+// 如查询串绑定失败时报 uid 而不是 json 标签里的 user_id。
+var validators = map[string]*validator.Validate{
+	"json":   newValidator("json"),
+	"form":   newValidator("form"),
+	"uri":    newValidator("uri"),
+	"header": newValidator("header"),
+}
+
+func newValidator(tag string) *validator.Validate {
+	v := validator.New()
+	// 错误里的字段名使用请求中实际出现的名字，没有标签时与 mapStruct 一样回退到 Go 字段名
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		switch name {
+		case "-":
+			return ""
+		case "":
+			return f.Name
+		}
+		return name
+	})
+	return v
+}
+
+// Binding 把请求的某一部分解码到 obj，并执行 validate 标签校验。
+type Binding interface {
+	Name() string
+	Bind(c *Context, obj any) error
+}
+
+// 内置的 Binding 实现。
+var (
+	JSONBinding   Binding = jsonBinding{}
+	QueryBinding  Binding = queryBinding{}
+	FormBinding   Binding = formBinding{}
+	URIBinding    Binding = uriBinding{}
+	HeaderBinding Binding = headerBinding{}
+)
+
+// FieldError 描述单个字段的校验失败。
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError 汇总一次绑定中所有字段的校验失败。
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Message)
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// validateStruct 只校验结构体（或其指针），其余类型直接放行；tag 决定错误中的字段名。
+func validateStruct(obj any, tag string) error {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	err := validators[tag].Struct(obj)
+	var ves validator.ValidationErrors
+	if !errors.As(err, &ves) {
+		return err
+	}
+	out := &ValidationError{Fields: make([]FieldError, 0, len(ves))}
+	for _, fe := range ves {
+		out.Fields = append(out.Fields, FieldError{
+			Field:   fe.Field(),
+			Tag:     fe.Tag(),
+			Param:   fe.Param(),
+			Message: fieldMessage(fe),
+		})
+	}
+	return out
+}
+
+// fieldMessage 把 validator 的底层错误翻译成适合前端展示的消息。
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fe.Field() + " is required"
+	case "email":
+		return fe.Field() + " must be a valid email"
+	case "min":
+		return fe.Field() + " must be at least " + fe.Param()
+	case "max":
+		return fe.Field() + " must be at most " + fe.Param()
+	case "oneof":
+		return fe.Field() + " must be one of [" + fe.Param() + "]"
+	}
+	return fe.Field() + " failed on the '" + fe.Tag() + "' rule"
+}
+
+type jsonBinding struct{}
+
+func (jsonBinding) Name() string { return "json" }
+
+func (jsonBinding) Bind(c *Context, obj any) error {
+	if c.Req.Body == nil {
+		return errors.New("request body is empty")
+	}
+	if err := json.NewDecoder(c.Req.Body).Decode(obj); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("request body is empty")
+		}
+		return err
+	}
+	return validateStruct(obj, "json")
+}
+
+type queryBinding struct{}
+
+func (queryBinding) Name() string { return "query" }
+
+func (queryBinding) Bind(c *Context, obj any) error {
+	if err := mapValues(obj, c.query(), "form"); err != nil {
+		return err
+	}
+	return validateStruct(obj, "form")
+}
+
+type formBinding struct{}
+
+func (formBinding) Name() string { return "form" }
+
+func (formBinding) Bind(c *Context, obj any) error {
+	if err := c.parseForm(); err != nil {
+		return err
+	}
+	if err := mapValues(obj, c.Req.Form, "form"); err != nil {
+		return err
+	}
+	return validateStruct(obj, "form")
+}
+
+type uriBinding struct{}
+
+func (uriBinding) Name() string { return "uri" }
+
+func (uriBinding) Bind(c *Context, obj any) error {
+	values := make(map[string][]string, len(c.Params))
+	for k, v := range c.Params {
+		values[k] = []string{v}
+	}
+	if err := mapValues(obj, values, "uri"); err != nil {
+		return err
+	}
+	return validateStruct(obj, "uri")
+}
+
+type headerBinding struct{}
+
+func (headerBinding) Name() string { return "header" }
+
+func (headerBinding) Bind(c *Context, obj any) error {
+	if err := mapValuesFunc(obj, "header", func(key string) ([]string, bool) {
+		v, ok := c.Req.Header[http.CanonicalHeaderKey(key)]
+		return v, ok
+	}); err != nil {
+		return err
+	}
+	return validateStruct(obj, "header")
+}
+
+// bindingFor 根据方法与 Content-Type 选择 Binding：GET/HEAD 读取查询串，其余按请求体类型。
+func bindingFor(method, contentType string) Binding {
+	if method == http.MethodGet || method == http.MethodHead {
+		return QueryBinding
+	}
+	ct, _, _ := mime.ParseMediaType(contentType)
+	switch ct {
+	case "application/json":
+		return JSONBinding
+	default:
+		return FormBinding
+	}
+}
+
+// ShouldBindWith 使用指定 Binding 绑定并校验，不写响应。
+func (c *Context) ShouldBindWith(obj any, b Binding) error {
+	return b.Bind(c, obj)
+}
+
+// ShouldBind 根据 Content-Type 自动选择 Binding，不写响应。
+func (c *Context) ShouldBind(obj any) error {
+	return c.ShouldBindWith(obj, bindingFor(c.Method, c.Req.Header.Get("Content-Type")))
+}
+
+// MustBindWith 绑定失败时记录 ErrorTypeBind 错误并立即以 400 响应、中止，
+// 响应由 ErrorHandler 渲染，默认会列出每个字段的错误。
+func (c *Context) MustBindWith(obj any, b Binding) error {
+	err := c.ShouldBindWith(obj, b)
+	if err != nil {
+		var ve *ValidationError
+		if errors.As(err, &ve) {
+			c.Error(err).SetType(ErrorTypeBind)
+		} else {
+			c.Error(fmt.Errorf("invalid %s input: %w", b.Name(), err)).SetType(ErrorTypeBind)
+		}
+		c.Abort()
+		c.Status(http.StatusBadRequest)
+		c.handleErrors()
+	}
+	return err
+}
+
+// Bind 根据 Content-Type 自动选择 Binding，失败时返回 400。
+func (c *Context) Bind(obj any) error {
+	return c.MustBindWith(obj, bindingFor(c.Method, c.Req.Header.Get("Content-Type")))
+}
+
+// BindJSON 解码 JSON 请求体，失败时返回 400。
+func (c *Context) BindJSON(obj any) error { return c.MustBindWith(obj, JSONBinding) }
+
+// BindQuery 绑定查询串（form 标签），失败时返回 400。
+func (c *Context) BindQuery(obj any) error { return c.MustBindWith(obj, QueryBinding) }
+
+// BindForm 绑定表单与查询串（form 标签），失败时返回 400。
+func (c *Context) BindForm(obj any) error { return c.MustBindWith(obj, FormBinding) }
+
+// BindURI 绑定路由参数（uri 标签），失败时返回 400。
+func (c *Context) BindURI(obj any) error { return c.MustBindWith(obj, URIBinding) }
+
+// BindHeader 绑定请求头（header 标签），失败时返回 400。
+func (c *Context) BindHeader(obj any) error { return c.MustBindWith(obj, HeaderBinding) }
+
+// mapValues 按 tag 把 map[string][]string 写入结构体字段。
+func mapValues(obj any, values map[string][]string, tag string) error {
+	return mapValuesFunc(obj, tag, func(key string) ([]string, bool) {
+		v, ok := values[key]
+		return v, ok
+	})
+}
+
+func mapValuesFunc(obj any, tag string, lookup func(string) ([]string, bool)) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return errors.New("tinygee: binding target must be a non-nil pointer")
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return errors.New("tinygee: binding target must point to a struct")
+	}
+	return mapStruct(v, tag, lookup)
+}
+
+func mapStruct(v reflect.Value, tag string, lookup func(string) ([]string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := v.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		// 匿名嵌入的结构体展开处理
+		if sf.Anonymous && name == "" && fv.Kind() == reflect.Struct {
+			if err := mapStruct(fv, tag, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		vals, ok := lookup(name)
+		if !ok || len(vals) == 0 {
+			continue
+		}
+		if err := setField(fv, vals); err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setField(fv reflect.Value, vals []string) error {
+	switch fv.Kind() {
+	case reflect.Pointer:
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return setField(fv.Elem(), vals)
+	case reflect.Slice:
+		slice := reflect.MakeSlice(fv.Type(), len(vals), len(vals))
+		for i, s := range vals {
+			if err := setScalar(slice.Index(i), s); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+	return setScalar(fv, vals[0])
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setScalar(fv reflect.Value, s string) error {
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		if s == "" {
+			s = "false"
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s == "" {
+			s = "0"
+		}
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s == "" {
+			s = "0"
+		}
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if s == "" {
+			s = "0"
+		}
+		f, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported kind %s", fv.Kind())
+	}
+	return nil
+}
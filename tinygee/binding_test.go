@@ -0,0 +1,176 @@
+package tinygee
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type createUserReq struct {
+	Email string   `json:"email" form:"email" validate:"required,email"`
+	Name  string   `json:"name" form:"name" validate:"required,min=2"`
+	Age   int      `json:"age" form:"age" validate:"omitempty,min=0,max=150"`
+	Tags  []string `json:"tags" form:"tag"`
+}
+
+func TestBindJSONValidation(t *testing.T) {
+	engine := New()
+	engine.POST("/users", func(c *Context) {
+		var req createUserReq
+		if err := c.BindJSON(&req); err != nil {
+			return
+		}
+		c.JSON(http.StatusOK, req)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"email":"bad","age":200}`))
+	req.Header.Set("Content-Type", "application/json")
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("want 400 got %d", w.Code)
+	}
+	var body struct {
+		Error  string       `json:"error"`
+		Fields []FieldError `json:"fields"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	got := map[string]string{}
+	for _, f := range body.Fields {
+		got[f.Field] = f.Tag
+	}
+	want := map[string]string{"email": "email", "name": "required", "age": "max"}
+	for field, tag := range want {
+		if got[field] != tag {
+			t.Fatalf("field %s: want tag %s, got fields %+v", field, tag, body.Fields)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"email":`))
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("malformed json: want 400 got %d", w.Code)
+	}
+}
+
+func TestShouldBindPicksBinding(t *testing.T) {
+	engine := New()
+	engine.Any("/users", func(c *Context) {
+		var req createUserReq
+		if err := c.ShouldBind(&req); err != nil {
+			c.String(http.StatusBadRequest, "%v", err)
+			return
+		}
+		c.String(http.StatusOK, "%s|%s|%d|%s", req.Email, req.Name, req.Age, strings.Join(req.Tags, ","))
+	})
+
+	form := url.Values{"email": {"a@b.co"}, "name": {"ann"}, "age": {"30"}, "tag": {"x", "y"}}
+	cases := []struct {
+		name string
+		req  *http.Request
+	}{
+		{"query", httptest.NewRequest(http.MethodGet, "/users?"+form.Encode(), nil)},
+		{"json", func() *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "/users",
+				strings.NewReader(`{"email":"a@b.co","name":"ann","age":30,"tags":["x","y"]}`))
+			r.Header.Set("Content-Type", "application/json; charset=utf-8")
+			return r
+		}()},
+		{"form", func() *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			return r
+		}()},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, tc.req)
+			if w.Code != http.StatusOK || w.Body.String() != "a@b.co|ann|30|x,y" {
+				t.Fatalf("got %d %q", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBindURIAndHeader(t *testing.T) {
+	type params struct {
+		ID   uint   `uri:"id" validate:"required"`
+		Slug string `uri:"slug" validate:"required,alphanum"`
+	}
+	type headers struct {
+		Token   string `header:"X-Token" validate:"required"`
+		Retries *int   `header:"x-retries"`
+	}
+	engine := New()
+	engine.GET("/posts/:id/:slug", func(c *Context) {
+		var p params
+		var h headers
+		if c.BindURI(&p) != nil || c.BindHeader(&h) != nil {
+			return
+		}
+		c.String(http.StatusOK, "%d %s %s %d", p.ID, p.Slug, h.Token, *h.Retries)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/7/hello", nil)
+	req.Header.Set("X-Token", "t1")
+	req.Header.Set("X-Retries", "3")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Body.String() != "7 hello t1 3" {
+		t.Fatalf("unexpected body %d %q", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/posts/abc/hello", nil)
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("non-numeric id: want 400 got %d", w.Code)
+	}
+}
+
+// 校验错误里的字段名随绑定变化：查询串报 form 标签，JSON 报 json 标签
+func TestBindingErrorFieldNames(t *testing.T) {
+	type filter struct {
+		UserID int    `json:"user_id" form:"uid" validate:"required"`
+		Token  string `json:"token" header:"X-Token" validate:"required"`
+	}
+	engine := New()
+	engine.GET("/query", func(c *Context) {
+		var f filter
+		err := c.ShouldBindWith(&f, QueryBinding)
+		c.String(http.StatusBadRequest, "%v", err)
+	})
+	engine.GET("/header", func(c *Context) {
+		var f filter
+		err := c.ShouldBindWith(&f, HeaderBinding)
+		c.String(http.StatusBadRequest, "%v", err)
+	})
+	engine.POST("/json", func(c *Context) {
+		var f filter
+		err := c.ShouldBindWith(&f, JSONBinding)
+		c.String(http.StatusBadRequest, "%v", err)
+	})
+
+	cases := []struct {
+		req  *http.Request
+		want string
+	}{
+		{httptest.NewRequest(http.MethodGet, "/query", nil), "validation failed: uid is required; Token is required"},
+		{httptest.NewRequest(http.MethodGet, "/header", nil), "validation failed: UserID is required; X-Token is required"},
+		{httptest.NewRequest(http.MethodPost, "/json", strings.NewReader(`{}`)), "validation failed: user_id is required; token is required"},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, tc.req)
+		if w.Body.String() != tc.want {
+			t.Errorf("%s: got %q want %q", tc.req.URL.Path, w.Body.String(), tc.want)
+		}
+	}
+}
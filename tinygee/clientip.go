@@ -0,0 +1,52 @@
+package tinygee
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// ClientIP 返回客户端地址。RemoteAddr 属于 Engine.TrustedProxies 时，
+// 从右向左跳过可信代理取 X-Forwarded-For 中的第一个地址，其次取 X-Real-IP；
+// 否则直接使用 RemoteAddr，防止客户端伪造转发头。
+func (c *Context) ClientIP() string {
+	remote := c.Req.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if c.engine == nil || !c.engine.trusted(remote) {
+		return remote
+	}
+	if xff := c.Req.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(hops[i])
+			if _, err := netip.ParseAddr(ip); err != nil {
+				break
+			}
+			if i == 0 || !c.engine.trusted(ip) {
+				return ip
+			}
+		}
+	}
+	if ip := strings.TrimSpace(c.Req.Header.Get("X-Real-IP")); ip != "" {
+		if _, err := netip.ParseAddr(ip); err == nil {
+			return ip
+		}
+	}
+	return remote
+}
+
+func (e *Engine) trusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range e.TrustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
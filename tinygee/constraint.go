@@ -0,0 +1,152 @@
+package tinygee
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// paramConstraint 限制 :param 段能匹配的值，写法为 :name<int>、:name<uuid> 或 :name<正则>。
+type paramConstraint struct {
+	expr  string
+	match func(string) bool
+}
+
+// 内置约束使用手写匹配，避免热路径上的正则开销。
+var namedConstraints = map[string]func(string) bool{
+	"int":   isInt,
+	"uint":  isUint,
+	"uuid":  isUUID,
+	"alpha": isAlpha,
+}
+
+// splitParam 把 ":id<int>" 拆成名字 "id" 与约束表达式 "int"，无约束时 expr 为空。
+func splitParam(seg string) (name, expr string) {
+	name = seg[1:]
+	if i := strings.IndexByte(name, '<'); i >= 0 {
+		return name[:i], strings.TrimSuffix(name[i+1:], ">")
+	}
+	return name, ""
+}
+
+// compileConstraint 解析约束表达式，非法正则在注册阶段 panic。
+// 正则会被整体锚定，只有整段匹配才算命中。
+func compileConstraint(expr, pattern string) *paramConstraint {
+	if expr == "" {
+		return nil
+	}
+	if fn, ok := namedConstraints[expr]; ok {
+		return &paramConstraint{expr: expr, match: fn}
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("tinygee: invalid constraint <%s> in route %q: %v", expr, pattern, err))
+	}
+	return &paramConstraint{expr: expr, match: re.MatchString}
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isInt 与 isUint 还要求值落在 int/uint 范围内，ParamInt/ParamUint 因此不会溢出。
+// 9 位以内在 32/64 位平台都不会溢出，更长时才调用 strconv 判断范围。
+func isInt(s string) bool {
+	digits := s
+	if digits != "" && digits[0] == '-' {
+		digits = digits[1:]
+	}
+	if !isDigits(digits) {
+		return false
+	}
+	if len(digits) <= 9 {
+		return true
+	}
+	_, err := strconv.ParseInt(s, 10, strconv.IntSize)
+	return err == nil
+}
+
+func isUint(s string) bool {
+	if !isDigits(s) {
+		return false
+	}
+	if len(s) <= 9 {
+		return true
+	}
+	_, err := strconv.ParseUint(s, 10, strconv.IntSize)
+	return err == nil
+}
+
+func isAlpha(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i] | 0x20; c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// isUUID 只接受 8-4-4-4-12 的标准写法。
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			c := s[i]
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ParamInt 把路由参数解析为 int。:name<int> 约束只匹配 int 范围内的值，
+// 搭配使用时不会失败；无约束的参数超出范围时返回 strconv.ErrRange。
+func (c *Context) ParamInt(key string) (int, error) {
+	return strconv.Atoi(c.Param(key))
+}
+
+// ParamUint 把路由参数解析为 uint，适合数据库自增主键。
+func (c *Context) ParamUint(key string) (uint, error) {
+	n, err := strconv.ParseUint(c.Param(key), 10, strconv.IntSize)
+	return uint(n), err
+}
+
+var errInvalidUUID = errors.New("tinygee: invalid UUID")
+
+// ParamUUID 把 8-4-4-4-12 写法的路由参数解析为 16 字节，大小写均可。
+// 返回值可直接转换为 github.com/google/uuid 的 uuid.UUID(v)，核心包因此不依赖第三方 UUID 库。
+func (c *Context) ParamUUID(key string) ([16]byte, error) {
+	var id [16]byte
+	s := c.Param(key)
+	if !isUUID(s) {
+		return id, errInvalidUUID
+	}
+	j := 0
+	for _, part := range []string{s[0:8], s[9:13], s[14:18], s[19:23], s[24:36]} {
+		n, _ := hex.Decode(id[j:], []byte(part))
+		j += n
+	}
+	return id, nil
+}
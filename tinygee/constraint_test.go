@@ -0,0 +1,101 @@
+package tinygee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConstrainedParams(t *testing.T) {
+	r := newRouter()
+	// 无约束参数先注册，匹配时仍应排在所有约束之后
+	for _, p := range []string{
+		"/todos/:name",
+		"/todos/:id<int>",
+		"/todos/:uuid<uuid>",
+		"/posts/:slug<[a-z0-9-]+>",
+		"/posts/:id<uint>/edit",
+		"/posts/:slug<[a-z0-9-]+>/:page<int>",
+	} {
+		r.addRoute(http.MethodGet, p, func(c *Context) {})
+	}
+
+	cases := []struct {
+		path    string
+		pattern string
+		params  map[string]string
+	}{
+		{"/todos/42", "/todos/:id<int>", map[string]string{"id": "42"}},
+		{"/todos/-3", "/todos/:id<int>", map[string]string{"id": "-3"}},
+		{"/todos/-9223372036854775808", "/todos/:id<int>", map[string]string{"id": "-9223372036854775808"}},
+		// 超出 int 范围的数字不满足 <int>，落到无约束参数
+		{"/todos/99999999999999999999", "/todos/:name", map[string]string{"name": "99999999999999999999"}},
+		{"/todos/0d3f2a2e-5b9c-4c1e-8f6a-2b7d9e1c4a10", "/todos/:uuid<uuid>",
+			map[string]string{"uuid": "0d3f2a2e-5b9c-4c1e-8f6a-2b7d9e1c4a10"}},
+		{"/todos/hello", "/todos/:name", map[string]string{"name": "hello"}},
+		{"/posts/hello-world", "/posts/:slug<[a-z0-9-]+>", map[string]string{"slug": "hello-world"}},
+		{"/posts/7/edit", "/posts/:id<uint>/edit", map[string]string{"id": "7"}},
+		// 7 同时满足 slug 约束，回溯后命中第二条
+		{"/posts/7/2", "/posts/:slug<[a-z0-9-]+>/:page<int>", map[string]string{"slug": "7", "page": "2"}},
+		{"/posts/18446744073709551616/edit", "", nil},
+		{"/posts/Hello", "", nil},
+		{"/posts/abc/edit", "", nil},
+		{"/posts/abc/x", "", nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			n, params := r.getRoute(http.MethodGet, tc.path)
+			if tc.pattern == "" {
+				if n != nil {
+					t.Fatalf("want no match, got %s", n.pattern)
+				}
+				return
+			}
+			if n == nil || n.pattern != tc.pattern {
+				t.Fatalf("want %s, got %+v", tc.pattern, n)
+			}
+			for k, v := range tc.params {
+				if params[k] != v {
+					t.Fatalf("param %s: want %q got %q", k, v, params[k])
+				}
+			}
+		})
+	}
+}
+
+func TestTypedParamAccessors(t *testing.T) {
+	engine := New()
+	engine.GET("/items/:id<int>/:owner<uuid>", func(c *Context) {
+		id, err1 := c.ParamInt("id")
+		uid, err2 := c.ParamUint("id")
+		owner, err3 := c.ParamUUID("owner")
+		if err1 != nil || err2 != nil || err3 != nil {
+			c.String(http.StatusInternalServerError, "%v %v %v", err1, err2, err3)
+			return
+		}
+		c.String(http.StatusOK, "%d %d %x", id, uid, owner)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/12/0D3F2A2E-5B9C-4C1E-8F6A-2B7D9E1C4A10", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "12 12 0d3f2a2e5b9c4c1e8f6a2b7d9e1c4a10" {
+		t.Fatalf("unexpected %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/abc/0d3f2a2e-5b9c-4c1e-8f6a-2b7d9e1c4a10", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("constraint mismatch should 404, got %d", w.Code)
+	}
+}
+
+func TestURLChecksConstraint(t *testing.T) {
+	r := New()
+	r.GET("/todos/:id<int>", func(c *Context) {}).Name("todo")
+	if got, err := r.URL("todo", "id", "5"); err != nil || got != "/todos/5" {
+		t.Fatalf("URL = %q, %v", got, err)
+	}
+	if _, err := r.URL("todo", "id", "five"); err == nil {
+		t.Fatal("value violating the constraint should fail")
+	}
+}
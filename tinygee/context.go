@@ -1,36 +1,107 @@
 package tinygee
 
 import (
-	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"sync"
 )
 
+// abortIndex 足够大，Next 的循环在 Abort 之后不会再执行任何 handler。
+const abortIndex = math.MaxInt32
+
 // Context 封装一次 HTTP 请求的上下文。
-// 后续会扩展 Params、中间件等字段。
+// Engine 通过对象池复用 Context，请求结束后不要再持有它或其中的 Params。
 type Context struct {
-	Writer http.ResponseWriter
+	// Writer 始终是包装后的 ResponseWriter，可通过 Writer.Status() 读取实际状态码
+	Writer ResponseWriter
 	Req    *http.Request
 
 	Path   string
 	Method string
 
-	StatusCode int
-	Params     map[string]string
+	Params map[string]string
+
+	// Errors 收集 handler 与中间件通过 Error 记录的错误
+	Errors ErrorList
 
 	handlers []HandlerFunc
 	index    int
+	fullPath string
+
+	// Keys 是请求级键值存储，供中间件之间传递数据，不与路由参数混用
+	mu   sync.RWMutex
+	Keys map[string]any
+
+	writermem   responseWriter
+	engine      *Engine
+	paramValues []string   // 路由匹配时按顺序收集的通配段值，随 Context 复用
+	queryCache  url.Values // 懒加载的查询串，避免重复解析
+
+	handlingErrors bool // 防止 ErrorHandler 渲染失败时递归
 }
 
 // NewContext 创建上下文对象。
 func NewContext(w http.ResponseWriter, req *http.Request) *Context {
-	return &Context{
-		Writer: w,
+	c := &Context{
 		Req:    req,
 		Path:   req.URL.Path,
 		Method: req.Method,
 		index:  -1,
 	}
+	c.writermem.reset(w)
+	c.Writer = &c.writermem
+	return c
+}
+
+// reset 让对象池取出的 Context 进入新请求的初始状态，保留已分配的 map/切片。
+func (c *Context) reset(w http.ResponseWriter, req *http.Request) {
+	c.writermem.reset(w)
+	c.Writer = &c.writermem
+	c.Req = req
+	c.Path = req.URL.Path
+	c.Method = req.Method
+	clear(c.Params)
+	c.mu.Lock()
+	clear(c.Keys)
+	c.mu.Unlock()
+	c.handlers = nil
+	c.index = -1
+	c.fullPath = ""
+	c.paramValues = c.paramValues[:0]
+	c.queryCache = nil
+	c.Errors = c.Errors[:0]
+	c.handlingErrors = false
+}
+
+// setParams 把匹配阶段收集的值按注册时解析的参数名写入 Params；
+// unescape 为 true 时值来自原始路径，需要先反转义。
+func (c *Context) setParams(names []string, unescape bool) {
+	if len(names) == 0 {
+		return
+	}
+	if c.Params == nil {
+		c.Params = make(map[string]string, len(names))
+	}
+	for i, name := range names {
+		if name == "" {
+			continue
+		}
+		v := c.paramValues[i]
+		if unescape {
+			if u, err := url.PathUnescape(v); err == nil {
+				v = u
+			}
+		}
+		c.Params[name] = v
+	}
+}
+
+// FullPath 返回命中路由的注册模式，如 "/users/:id"；未命中时为空串。
+// 按模式而不是实际路径统计或记录日志，可以避免高基数。
+func (c *Context) FullPath() string {
+	return c.fullPath
 }
 
 // Next 执行下一个中间件/处理器
@@ -42,6 +113,82 @@ func (c *Context) Next() {
 	}
 }
 
+// Abort 阻止后续 handler 执行，当前 handler 仍会正常返回。
+// 即便在 Next 返回后才调用，也能阻止链上剩余的 handler。
+func (c *Context) Abort() {
+	c.index = abortIndex
+}
+
+// IsAborted 判断当前请求是否已被中止。
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
+}
+
+// AbortWithStatus 写入状态码并中止。
+func (c *Context) AbortWithStatus(code int) {
+	c.Status(code)
+	c.Writer.WriteHeaderNow()
+	c.Abort()
+}
+
+// AbortWithStatusJSON 以 JSON 响应并中止。
+func (c *Context) AbortWithStatusJSON(code int, obj any) {
+	c.Abort()
+	c.JSON(code, obj)
+}
+
+// Set 在请求级存储中保存键值。
+func (c *Context) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Keys == nil {
+		c.Keys = make(map[string]any)
+	}
+	c.Keys[key] = value
+}
+
+// Get 读取请求级存储中的值。
+func (c *Context) Get(key string) (value any, exists bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, exists = c.Keys[key]
+	return
+}
+
+// MustGet 读取值，不存在时 panic。
+func (c *Context) MustGet(key string) any {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic("tinygee: key \"" + key + "\" does not exist")
+}
+
+// GetString 读取字符串值，不存在或类型不符时返回空串。
+func (c *Context) GetString(key string) string {
+	if value, ok := c.Get(key); ok {
+		s, _ := value.(string)
+		return s
+	}
+	return ""
+}
+
+// GetInt 读取 int 值，不存在或类型不符时返回 0。
+func (c *Context) GetInt(key string) int {
+	if value, ok := c.Get(key); ok {
+		i, _ := value.(int)
+		return i
+	}
+	return 0
+}
+
+// RequestIDKey 是 middleware.RequestID 在 Keys 中保存请求 ID 的键。
+const RequestIDKey = "requestID"
+
+// RequestID 返回 middleware.RequestID 分配的请求 ID，未启用时为空串。
+func (c *Context) RequestID() string {
+	return c.GetString(RequestIDKey)
+}
+
 // Param 获取路由参数
 func (c *Context) Param(key string) string {
 	if c.Params == nil {
@@ -50,12 +197,18 @@ func (c *Context) Param(key string) string {
 	return c.Params[key]
 }
 
-// Status 设置状态码。
+// Status 设置状态码，响应头在第一次写响应体时才真正发送。
 func (c *Context) Status(code int) {
-	c.StatusCode = code
 	c.Writer.WriteHeader(code)
 }
 
+// StatusCode 返回当前响应的状态码，原先的 StatusCode 字段已改为此方法。
+//
+// Deprecated: 使用 c.Writer.Status()。
+func (c *Context) StatusCode() int {
+	return c.Writer.Status()
+}
+
 // SetHeader 设置响应头。
 func (c *Context) SetHeader(key, value string) {
 	c.Writer.Header().Set(key, value)
@@ -68,11 +221,10 @@ func (c *Context) String(code int, format string, values ...any) {
 	_, _ = fmt.Fprintf(c.Writer, format, values...)
 }
 
-// JSON 返回 JSON。
+// JSON 返回 JSON，编码失败时交给 Engine.OnRenderError 并返回 500。
 func (c *Context) JSON(code int, obj any) {
-	c.SetHeader("Content-Type", "application/json; charset=utf-8")
-	c.Status(code)
-	_ = json.NewEncoder(c.Writer).Encode(obj)
+	body, err := encodeJSON(obj, true, "")
+	c.render(code, MIMEJSON+"; charset=utf-8", body, err)
 }
 
 // Data 返回字节数据。
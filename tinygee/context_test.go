@@ -0,0 +1,145 @@
+package tinygee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestAbortStopsChain(t *testing.T) {
+	engine := New()
+	var order []string
+	engine.Use(func(c *Context) {
+		order = append(order, "outer")
+		c.Next()
+	})
+	engine.Use(func(c *Context) {
+		order = append(order, "guard")
+		c.AbortWithStatusJSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+	})
+	engine.GET("/secret", func(c *Context) {
+		order = append(order, "handler")
+		c.String(http.StatusOK, "secret")
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/secret", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("want 403 got %d", w.Code)
+	}
+	if len(order) != 2 || order[1] != "guard" {
+		t.Fatalf("handler should not run after abort: %v", order)
+	}
+}
+
+func TestAbortAfterNext(t *testing.T) {
+	engine := New()
+	ran := false
+	engine.Use(func(c *Context) {
+		c.Next()
+		c.Abort()
+		if !c.IsAborted() {
+			t.Errorf("IsAborted should be true")
+		}
+	})
+	engine.GET("/ping", func(c *Context) {
+		ran = true
+		c.String(http.StatusOK, "pong")
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	if !ran || w.Code != http.StatusOK {
+		t.Fatalf("handler should run once, ran=%v code=%d", ran, w.Code)
+	}
+}
+
+func TestContextKeys(t *testing.T) {
+	engine := New()
+	engine.Use(func(c *Context) {
+		c.Set("user", "alice")
+		c.Set("level", 3)
+		c.Next()
+	})
+	engine.GET("/me/:user", func(c *Context) {
+		if c.GetString("user") != "alice" || c.GetInt("level") != 3 {
+			t.Errorf("unexpected keys %v", c.Keys)
+		}
+		if c.GetString("level") != "" || c.GetInt("user") != 0 {
+			t.Errorf("type mismatch should return zero value")
+		}
+		if _, ok := c.Get("missing"); ok {
+			t.Errorf("missing key reported as present")
+		}
+		if c.Param("user") != "bob" {
+			t.Errorf("route param polluted: %q", c.Param("user"))
+		}
+		c.String(http.StatusOK, "%v", c.MustGet("user"))
+	})
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/me/bob", nil))
+	if w.Body.String() != "alice" {
+		t.Fatalf("unexpected body %q", w.Body.String())
+	}
+}
+
+func TestMustGetPanics(t *testing.T) {
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("MustGet should panic on missing key")
+		}
+	}()
+	c.MustGet("user")
+}
+
+func TestFullPath(t *testing.T) {
+	engine := New()
+	var got []string
+	engine.Use(func(c *Context) {
+		c.Next()
+		got = append(got, c.FullPath())
+	})
+	engine.GET("/users/:id<int>/files/*path", func(c *Context) {})
+
+	for _, p := range []string{"/users/1/files/a/b", "/missing"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, p, nil))
+	}
+	if len(got) != 2 || got[0] != "/users/:id<int>/files/*path" || got[1] != "" {
+		t.Fatalf("unexpected full paths %q", got)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	engine := New()
+	engine.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	var ip string
+	engine.GET("/", func(c *Context) { ip = c.ClientIP() })
+
+	cases := []struct {
+		remote, xff, realIP, want string
+	}{
+		// 不可信来源的转发头一律忽略
+		{"203.0.113.9:1234", "1.2.3.4", "5.6.7.8", "203.0.113.9"},
+		// 跳过链尾的可信代理，取第一个不可信地址
+		{"10.0.0.1:1234", "1.2.3.4, 198.51.100.7, 10.0.0.2", "", "198.51.100.7"},
+		{"10.0.0.1:1234", "10.0.0.3, 10.0.0.2", "", "10.0.0.3"},
+		{"10.0.0.1:1234", "", "5.6.7.8", "5.6.7.8"},
+		{"10.0.0.1:1234", "garbage", "", "10.0.0.1"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tc.remote
+		if tc.xff != "" {
+			req.Header.Set("X-Forwarded-For", tc.xff)
+		}
+		if tc.realIP != "" {
+			req.Header.Set("X-Real-IP", tc.realIP)
+		}
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+		if ip != tc.want {
+			t.Fatalf("%+v: got %q", tc, ip)
+		}
+	}
+}
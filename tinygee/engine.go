@@ -1,58 +1,222 @@
 package tinygee
 
-import "net/http"
+import (
+	"net/http"
+	"net/netip"
+	"os"
+	"sync"
+	"time"
+)
 
 // Engine 实现最小的 HTTP 路由引擎。
 type Engine struct {
 	router *router
-	groups []*RouterGroup
+	// hosts 是 Host 创建的虚拟主机路由，按注册顺序保存
+	hosts []*router
+	// root 是根分组，Engine 上的路由注册方法都委托给它
+	root *RouterGroup
 	// 全局中间件
 	middlewares []HandlerFunc
+	// MaxMultipartMemory 是解析 multipart 表单时驻留内存的上限，超出部分写入临时文件
+	MaxMultipartMemory int64
+	// SecureJSONPrefix 是 SecureJSON 在数组响应前添加的前缀，防止 JSON 劫持
+	SecureJSONPrefix string
+	// OnRenderError 在响应编码失败时被调用，未设置时仅打印日志
+	OnRenderError func(c *Context, err error)
+	// ErrorHandler 渲染 Context.Error 收集的错误，nil 时使用 DefaultErrorHandler
+	ErrorHandler HandlerFunc
+	// ProblemDetails 为 true 时默认错误响应使用 RFC 7807 application/problem+json
+	ProblemDetails bool
+	// RedirectTrailingSlash 为 true 时，/foo/ 未命中而 /foo 存在（或相反）则重定向，默认开启
+	RedirectTrailingSlash bool
+	// RedirectFixedPath 为 true 时，清理 ..、重复的 / 并忽略大小写后再尝试匹配，命中则重定向
+	RedirectFixedPath bool
+	// UseRawPath 为 true 时使用 URL.RawPath 匹配，使 %2F 等转义字符留在同一段参数内
+	UseRawPath bool
+	// UnescapePathValues 在 UseRawPath 下把参数值反转义后再写入 Params
+	UnescapePathValues bool
+	// TrustedProxies 是可信反向代理的网段，ClientIP 只在 RemoteAddr 属于其中时才读取转发头
+	TrustedProxies []netip.Prefix
+	// DebugMode 为 true 时启动服务前打印路由表，New 根据环境变量 TINYGEE_MODE=debug 开启
+	DebugMode bool
+	// namedRoutes 保存 Route.Name 登记的路由
+	namedRoutes map[string]*route
+	// 未命中路由与方法不允许时的 handler，以及拼上全局中间件后的完整链
+	noRoute     []HandlerFunc
+	noMethod    []HandlerFunc
+	allNoRoute  []HandlerFunc
+	allNoMethod []HandlerFunc
+	// 服务端超时配置，0 表示不限制；流式响应（SSE、大文件）需谨慎设置 WriteTimeout
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// ShutdownTimeout 是 RunContext 收到取消信号后等待在途请求完成的最长时间
+	ShutdownTimeout time.Duration
+	// 生命周期钩子与当前运行的 http.Server
+	serverMu   sync.Mutex
+	server     *http.Server
+	onStart    []func()
+	onShutdown []func()
+	// Context 对象池，避免每个请求都分配新的 Context
+	pool sync.Pool
 }
 
 // New 创建引擎。
 func New() *Engine {
-	engine := &Engine{router: newRouter()}
-	engine.groups = []*RouterGroup{{engine: engine}}
+	engine := &Engine{
+		router:                newRouter(),
+		MaxMultipartMemory:    defaultMultipartMemory,
+		SecureJSONPrefix:      "while(1);",
+		ReadHeaderTimeout:     10 * time.Second,
+		IdleTimeout:           60 * time.Second,
+		ShutdownTimeout:       10 * time.Second,
+		DebugMode:             os.Getenv("TINYGEE_MODE") == "debug",
+		RedirectTrailingSlash: true,
+		UnescapePathValues:    true,
+	}
+	engine.root = &RouterGroup{engine: engine}
+	engine.rebuildMissHandlers()
+	engine.pool.New = func() any {
+		return &Context{engine: engine, index: -1}
+	}
 	return engine
 }
 
-// addRoute 注册路由。
-func (e *Engine) addRoute(method, pattern string, handler HandlerFunc) {
-	e.router.addRoute(method, pattern, handler)
+// addRoute 在分组所属主机的路由树上注册路由，并预先拼好该路由的完整中间件链。
+func (e *Engine) addRoute(g *RouterGroup, method, pattern string, handlers []HandlerFunc) *Route {
+	rt := e.routerFor(g.host).addRoute(method, pattern, handlers...)
+	rt.group = g
+	rt.handlers = e.combineHandlers(g, rt.own)
+	return &Route{engine: e, routes: []*route{rt}}
+}
+
+// combineHandlers 按执行顺序拼接：全局中间件 -> 各级分组中间件（外层在前）-> 路由自身的 handler。
+// 分组中间件只来自注册路由的分组及其祖先，与路径前缀无关。
+func (e *Engine) combineHandlers(g *RouterGroup, own []HandlerFunc) []HandlerFunc {
+	var chain []*RouterGroup
+	for ; g != nil; g = g.parent {
+		chain = append(chain, g)
+	}
+	handlers := append([]HandlerFunc(nil), e.middlewares...)
+	for i := len(chain) - 1; i >= 0; i-- {
+		handlers = append(handlers, chain[i].middlewares...)
+	}
+	return append(handlers, own...)
+}
+
+// rebuildHandlers 在中间件变化后重建所有路由的中间件链，
+// 保证先注册路由、后调用 Use 的写法依然生效。
+func (e *Engine) rebuildHandlers() {
+	for _, r := range e.routers() {
+		for _, rt := range r.routes {
+			rt.handlers = e.combineHandlers(rt.group, rt.own)
+		}
+	}
+	e.rebuildMissHandlers()
+}
+
+// routers 返回默认路由树与所有虚拟主机路由树。
+func (e *Engine) routers() []*router {
+	return append([]*router{e.router}, e.hosts...)
+}
+
+// NoRoute 设置未命中任何路由时的 handler，执行前状态码已设为 404。
+func (e *Engine) NoRoute(handlers ...HandlerFunc) {
+	e.noRoute = handlers
+	e.rebuildMissHandlers()
+}
+
+// NoMethod 设置路径存在但方法不匹配时的 handler，执行前已写好 405 与 Allow 头。
+func (e *Engine) NoMethod(handlers ...HandlerFunc) {
+	e.noMethod = handlers
+	e.rebuildMissHandlers()
+}
+
+// rebuildMissHandlers 让 404/405 也经过全局中间件，未设置时使用默认 JSON 错误。
+func (e *Engine) rebuildMissHandlers() {
+	noRoute, noMethod := e.noRoute, e.noMethod
+	if len(noRoute) == 0 {
+		noRoute = []HandlerFunc{defaultMissHandler(errNotFound)}
+	}
+	if len(noMethod) == 0 {
+		noMethod = []HandlerFunc{defaultMissHandler(errMethodNotAllowed)}
+	}
+	e.allNoRoute = append(append([]HandlerFunc(nil), e.middlewares...), noRoute...)
+	e.allNoMethod = append(append([]HandlerFunc(nil), e.middlewares...), noMethod...)
+}
+
+// Handle 注册任意方法的路由；handlers 中最后一个是业务处理器，前面的是仅作用于该路由的中间件。
+func (e *Engine) Handle(method, pattern string, handlers ...HandlerFunc) *Route {
+	return e.root.Handle(method, pattern, handlers...)
 }
 
 // GET 注册 GET 路由。
-func (e *Engine) GET(pattern string, handler HandlerFunc) {
-	e.addRoute(http.MethodGet, pattern, handler)
+func (e *Engine) GET(pattern string, handlers ...HandlerFunc) *Route {
+	return e.root.GET(pattern, handlers...)
 }
 
 // POST 注册 POST 路由。
-func (e *Engine) POST(pattern string, handler HandlerFunc) {
-	e.addRoute(http.MethodPost, pattern, handler)
+func (e *Engine) POST(pattern string, handlers ...HandlerFunc) *Route {
+	return e.root.POST(pattern, handlers...)
+}
+
+// PUT 注册 PUT 路由。
+func (e *Engine) PUT(pattern string, handlers ...HandlerFunc) *Route {
+	return e.root.PUT(pattern, handlers...)
 }
 
-// Use 注册全局中间件。
+// PATCH 注册 PATCH 路由。
+func (e *Engine) PATCH(pattern string, handlers ...HandlerFunc) *Route {
+	return e.root.PATCH(pattern, handlers...)
+}
+
+// DELETE 注册 DELETE 路由。
+func (e *Engine) DELETE(pattern string, handlers ...HandlerFunc) *Route {
+	return e.root.DELETE(pattern, handlers...)
+}
+
+// OPTIONS 注册 OPTIONS 路由。
+func (e *Engine) OPTIONS(pattern string, handlers ...HandlerFunc) *Route {
+	return e.root.OPTIONS(pattern, handlers...)
+}
+
+// HEAD 注册 HEAD 路由；未显式注册时 HEAD 请求会自动复用 GET 路由。
+func (e *Engine) HEAD(pattern string, handlers ...HandlerFunc) *Route {
+	return e.root.HEAD(pattern, handlers...)
+}
+
+// Any 为 anyMethods 中的所有方法注册同一组处理器。
+func (e *Engine) Any(pattern string, handlers ...HandlerFunc) *Route {
+	return e.root.Any(pattern, handlers...)
+}
+
+// Use 注册全局中间件，它们在所有分组中间件之前执行，404/405 也会经过。
 func (e *Engine) Use(m ...HandlerFunc) {
 	e.middlewares = append(e.middlewares, m...)
+	e.rebuildHandlers()
 }
 
 // ServeHTTP 实现 http.Handler。
 func (e *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	c := NewContext(w, req)
-	// 选择命中的分组中间件 + 全局中间件
-	for _, group := range e.groups {
-		if len(group.prefix) == 0 || hasPrefix(req.URL.Path, group.prefix) {
-			c.handlers = append(c.handlers, group.middlewares...)
-		}
-	}
-	c.handlers = append(c.handlers, e.middlewares...)
-	e.router.handle(c)
+	c := e.pool.Get().(*Context)
+	c.reset(w, req)
+	e.handle(c)
+	// 只调用了 Status 而没有写响应体时，确保状态码被发送
+	c.Writer.WriteHeaderNow()
+	e.pool.Put(c)
 }
 
-// Run 启动 HTTP 服务。
-func (e *Engine) Run(addr string) error {
-	return http.ListenAndServe(addr, e)
+// handle 选择路由树并执行，收集到错误但没有写响应时统一交给 ErrorHandler。
+func (e *Engine) handle(c *Context) {
+	r := e.router
+	if len(e.hosts) > 0 {
+		if hr := e.matchHost(c.Req.Host); hr != nil {
+			r = hr
+		}
+	}
+	r.handle(c)
+	c.handleErrors()
 }
 
 // hasPrefix 判断路由是否以分组前缀开头（保证 / 分隔）
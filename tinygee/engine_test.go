@@ -33,3 +33,67 @@ func TestEngineNotFound(t *testing.T) {
 		t.Fatalf("want 404 got %d", w.Code)
 	}
 }
+
+func TestEngineMethods(t *testing.T) {
+	engine := New()
+	handler := func(c *Context) { c.String(http.StatusOK, "%s", c.Method) }
+	engine.PUT("/item", handler)
+	engine.PATCH("/item", handler)
+	engine.DELETE("/item", handler)
+	engine.OPTIONS("/item", handler)
+	engine.Handle("PURGE", "/item", handler)
+	api := engine.Group("/api")
+	api.Any("/any", handler)
+
+	cases := []struct {
+		method, path string
+	}{
+		{http.MethodPut, "/item"},
+		{http.MethodPatch, "/item"},
+		{http.MethodDelete, "/item"},
+		{http.MethodOptions, "/item"},
+		{"PURGE", "/item"},
+		{http.MethodGet, "/api/any"},
+		{http.MethodPost, "/api/any"},
+		{http.MethodDelete, "/api/any"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || w.Body.String() != tc.method {
+			t.Fatalf("%s %s: got %d %q", tc.method, tc.path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestEngineHeadFallback(t *testing.T) {
+	engine := New()
+	engine.GET("/hello", func(c *Context) {
+		c.SetHeader("X-Route", "get")
+		c.String(http.StatusOK, "hello")
+	})
+
+	req := httptest.NewRequest(http.MethodHead, "/hello", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("X-Route") != "get" {
+		t.Fatalf("HEAD should reuse GET route, got %d", w.Code)
+	}
+}
+
+func TestEngineMethodNotAllowed(t *testing.T) {
+	engine := New()
+	engine.GET("/users/:id", func(c *Context) {})
+	engine.DELETE("/users/:id", func(c *Context) {})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("want 405 got %d", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != "GET, HEAD, DELETE" {
+		t.Fatalf("unexpected Allow header %q", allow)
+	}
+}
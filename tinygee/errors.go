@@ -0,0 +1,214 @@
+package tinygee
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrorType 标记错误的来源与可见性，可按位组合。
+type ErrorType uint64
+
+const (
+	// ErrorTypePrivate 是默认类型，错误信息不会返回给客户端
+	ErrorTypePrivate ErrorType = 1 << iota
+	// ErrorTypePublic 的错误信息可以直接展示给客户端
+	ErrorTypePublic
+	// ErrorTypeBind 表示请求绑定或校验失败
+	ErrorTypeBind
+	// ErrorTypeRender 表示响应编码失败
+	ErrorTypeRender
+	// ErrorTypeAny 匹配任意类型
+	ErrorTypeAny ErrorType = 1<<64 - 1
+)
+
+var (
+	errNotFound         = errors.New("not found")
+	errMethodNotAllowed = errors.New("method not allowed")
+)
+
+// Error 是 Context.Error 收集的带类型错误。
+type Error struct {
+	Err  error
+	Type ErrorType
+	// Meta 携带额外信息，problem+json 输出时作为扩展字段
+	Meta any
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// SetType 设置错误类型并返回自身，便于链式调用。
+func (e *Error) SetType(t ErrorType) *Error {
+	e.Type = t
+	return e
+}
+
+// SetMeta 设置附加信息并返回自身。
+func (e *Error) SetMeta(meta any) *Error {
+	e.Meta = meta
+	return e
+}
+
+// IsType 判断错误是否属于给定类型之一。
+func (e *Error) IsType(t ErrorType) bool {
+	return e.Type&t > 0
+}
+
+// ErrorList 是一次请求中收集到的全部错误，按发生顺序排列。
+type ErrorList []*Error
+
+// ByType 返回属于给定类型的错误。
+func (l ErrorList) ByType(t ErrorType) ErrorList {
+	var out ErrorList
+	for _, e := range l {
+		if e.IsType(t) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Last 返回最后一个错误，没有时返回 nil。
+func (l ErrorList) Last() *Error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l[len(l)-1]
+}
+
+// String 按行列出所有错误，便于写入日志。
+func (l ErrorList) String() string {
+	var sb strings.Builder
+	for i, e := range l {
+		fmt.Fprintf(&sb, "Error #%02d: %s\n", i+1, e.Err)
+	}
+	return sb.String()
+}
+
+// Error 把 err 记录到当前请求，返回的 *Error 可继续设置类型与附加信息。
+// 已是 *Error 的错误原样记录；err 为 nil 时 panic。
+func (c *Context) Error(err error) *Error {
+	if err == nil {
+		panic("tinygee: err is nil")
+	}
+	var parsed *Error
+	if !errors.As(err, &parsed) {
+		parsed = &Error{Err: err, Type: ErrorTypePrivate}
+	}
+	c.Errors = append(c.Errors, parsed)
+	return parsed
+}
+
+// AbortWithError 记录错误、设置状态码并中止；handler 链结束后由 Engine.ErrorHandler 渲染响应。
+func (c *Context) AbortWithError(code int, err error) *Error {
+	c.Abort()
+	c.Status(code)
+	return c.Error(err)
+}
+
+// handleErrors 在存在错误且尚未写出响应时调用 ErrorHandler；
+// 错误处理自身出错时不再递归，只发送状态码。
+func (c *Context) handleErrors() {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+	if c.handlingErrors {
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	c.handlingErrors = true
+	defer func() { c.handlingErrors = false }()
+	handler := DefaultErrorHandler
+	if c.engine != nil && c.engine.ErrorHandler != nil {
+		handler = c.engine.ErrorHandler
+	}
+	handler(c)
+}
+
+// DefaultErrorHandler 以最后一个错误渲染响应：状态码沿用已设置的 4xx/5xx，否则为 500。
+// 私有错误只返回状态码描述；Engine.ProblemDetails 为 true 时输出 RFC 7807 格式。
+func DefaultErrorHandler(c *Context) {
+	status := c.Writer.Status()
+	if status < http.StatusBadRequest {
+		status = http.StatusInternalServerError
+	}
+	last := c.Errors.Last()
+
+	msg := strings.ToLower(http.StatusText(status))
+	if last.IsType(ErrorTypePublic | ErrorTypeBind) {
+		msg = last.Error()
+	}
+	var ve *ValidationError
+	isValidation := errors.As(last, &ve)
+	if isValidation {
+		msg = "validation failed"
+	}
+
+	if c.engine != nil && c.engine.ProblemDetails {
+		p := &Problem{Status: status, Detail: msg, Instance: c.Path}
+		if isValidation {
+			p.Extensions = map[string]any{"fields": ve.Fields}
+		} else if m, ok := last.Meta.(map[string]any); ok {
+			p.Extensions = m
+		}
+		c.Problem(p)
+		return
+	}
+	body := map[string]any{"error": msg}
+	if isValidation {
+		body["fields"] = ve.Fields
+	}
+	c.JSON(status, body)
+}
+
+// MIMEProblemJSON 是 RFC 7807 规定的问题详情类型。
+const MIMEProblemJSON = "application/problem+json"
+
+// Problem 是 RFC 7807 问题详情，Extensions 中的字段会平铺到顶层。
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+// MarshalJSON 输出标准成员并平铺扩展字段；Type 为空时按规范使用 about:blank。
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	m["type"] = p.Type
+	if p.Type == "" {
+		m["type"] = "about:blank"
+	}
+	m["title"] = p.Title
+	if p.Title == "" {
+		m["title"] = http.StatusText(p.Status)
+	}
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return json.Marshal(m)
+}
+
+// Problem 以 application/problem+json 响应，状态码取 p.Status（为 0 时使用 500）。
+func (c *Context) Problem(p *Problem) {
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+	body, err := encodeJSON(p, true, "")
+	c.render(p.Status, MIMEProblemJSON, body, err)
+}
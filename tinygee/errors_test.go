@@ -0,0 +1,132 @@
+package tinygee
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNoRouteAndNoMethodRunGlobalMiddleware(t *testing.T) {
+	r := New()
+	var trace []string
+	r.Use(func(c *Context) {
+		trace = append(trace, "global")
+		c.Next()
+	})
+	r.Group("/api").Use(func(c *Context) { trace = append(trace, "group") })
+	r.GET("/api/items", func(c *Context) {})
+	r.NoRoute(func(c *Context) { c.String(c.Writer.Status(), "custom 404") })
+	r.NoMethod(func(c *Context) { c.String(c.Writer.Status(), "custom 405") })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/missing", nil))
+	if w.Code != http.StatusNotFound || w.Body.String() != "custom 404" {
+		t.Fatalf("no route: %d %q", w.Code, w.Body.String())
+	}
+	if len(trace) != 1 || trace[0] != "global" {
+		t.Fatalf("404 should only run global middleware, got %v", trace)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/items", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Body.String() != "custom 405" || w.Header().Get("Allow") != "GET, HEAD" {
+		t.Fatalf("no method: %d %q allow=%q", w.Code, w.Body.String(), w.Header().Get("Allow"))
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	r := New()
+	r.GET("/private", func(c *Context) {
+		c.AbortWithError(http.StatusBadGateway, errors.New("upstream secret"))
+	})
+	r.GET("/public", func(c *Context) {
+		c.Error(errors.New("quota exceeded")).SetType(ErrorTypePublic)
+		c.Status(http.StatusTooManyRequests)
+	})
+	r.GET("/written", func(c *Context) {
+		c.Error(errors.New("logged only"))
+		c.String(http.StatusOK, "ok")
+	})
+
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/private", http.StatusBadGateway, `{"error":"bad gateway"}`},
+		{"/public", http.StatusTooManyRequests, `{"error":"quota exceeded"}`},
+		{"/written", http.StatusOK, "ok"},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != tc.code || strings.TrimSpace(w.Body.String()) != tc.body {
+			t.Fatalf("%s: got %d %q", tc.path, w.Code, w.Body.String())
+		}
+	}
+
+	var seen ErrorList
+	r.ErrorHandler = func(c *Context) {
+		seen = c.Errors.ByType(ErrorTypeAny)
+		c.String(c.Writer.Status(), "handled")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/private", nil))
+	if w.Body.String() != "handled" || len(seen) != 1 || seen[0].Error() != "upstream secret" {
+		t.Fatalf("custom handler: %q %v", w.Body.String(), seen)
+	}
+}
+
+func TestProblemDetails(t *testing.T) {
+	r := New()
+	r.ProblemDetails = true
+	r.POST("/users", func(c *Context) {
+		var req struct {
+			Name string `json:"name" validate:"required"`
+		}
+		_ = c.BindJSON(&req)
+	})
+	r.GET("/teapot", func(c *Context) {
+		c.AbortWithError(http.StatusTeapot, errors.New("short and stout")).
+			SetType(ErrorTypePublic).
+			SetMeta(map[string]any{"pot": "brown"})
+	})
+
+	decode := func(w *httptest.ResponseRecorder) map[string]any {
+		t.Helper()
+		if ct := w.Header().Get("Content-Type"); ct != MIMEProblemJSON {
+			t.Fatalf("content type %q", ct)
+		}
+		var m map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return m
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/nope", nil))
+	m := decode(w)
+	if w.Code != http.StatusNotFound || m["type"] != "about:blank" || m["title"] != "Not Found" ||
+		m["status"] != float64(404) || m["detail"] != "not found" || m["instance"] != "/nope" {
+		t.Fatalf("404 problem: %v", m)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	m = decode(w)
+	if fields, ok := m["fields"].([]any); w.Code != http.StatusBadRequest || !ok || len(fields) != 1 {
+		t.Fatalf("bind problem: %d %v", w.Code, m)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/teapot", nil))
+	if m = decode(w); m["detail"] != "short and stout" || m["pot"] != "brown" {
+		t.Fatalf("meta problem: %v", m)
+	}
+}
@@ -0,0 +1,174 @@
+package tinygee
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultMultipartMemory 是 Engine.MaxMultipartMemory 的默认值。
+const defaultMultipartMemory = 32 << 20
+
+// Query 返回查询参数的第一个值，不存在时返回空串。
+func (c *Context) Query(key string) string {
+	value, _ := c.GetQuery(key)
+	return value
+}
+
+// DefaultQuery 返回查询参数，不存在时返回 defaultValue。
+func (c *Context) DefaultQuery(key, defaultValue string) string {
+	if value, ok := c.GetQuery(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// GetQuery 返回查询参数及其是否存在（?key= 也算存在）。
+func (c *Context) GetQuery(key string) (string, bool) {
+	if values, ok := c.query()[key]; ok && len(values) > 0 {
+		return values[0], true
+	}
+	return "", false
+}
+
+// QueryArray 返回同名查询参数的全部值，如 ?id=1&id=2。
+func (c *Context) QueryArray(key string) []string {
+	return c.query()[key]
+}
+
+// QueryMap 把 key[a]=1&key[b]=2 形式的查询参数收集为 map。
+func (c *Context) QueryMap(key string) map[string]string {
+	return bracketMap(c.query(), key)
+}
+
+func (c *Context) query() url.Values {
+	if c.queryCache == nil {
+		c.queryCache = c.Req.URL.Query()
+	}
+	return c.queryCache
+}
+
+// PostForm 返回请求体表单字段（urlencoded 或 multipart），不含查询串。
+func (c *Context) PostForm(key string) string {
+	value, _ := c.GetPostForm(key)
+	return value
+}
+
+// DefaultPostForm 返回表单字段，不存在时返回 defaultValue。
+func (c *Context) DefaultPostForm(key, defaultValue string) string {
+	if value, ok := c.GetPostForm(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// GetPostForm 返回表单字段及其是否存在。
+func (c *Context) GetPostForm(key string) (string, bool) {
+	if values := c.PostFormArray(key); len(values) > 0 {
+		return values[0], true
+	}
+	return "", false
+}
+
+// PostFormArray 返回同名表单字段的全部值。
+func (c *Context) PostFormArray(key string) []string {
+	if err := c.parseForm(); err != nil {
+		return nil
+	}
+	return c.Req.PostForm[key]
+}
+
+// PostFormMap 把 key[a]=1 形式的表单字段收集为 map。
+func (c *Context) PostFormMap(key string) map[string]string {
+	if err := c.parseForm(); err != nil {
+		return map[string]string{}
+	}
+	return bracketMap(c.Req.PostForm, key)
+}
+
+// FormFile 返回 multipart 表单中名为 name 的第一个文件。
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	if c.Req.MultipartForm == nil {
+		if err := c.Req.ParseMultipartForm(c.maxMultipartMemory()); err != nil {
+			return nil, err
+		}
+	}
+	f, fh, err := c.Req.FormFile(name)
+	if err != nil {
+		return nil, err
+	}
+	_ = f.Close()
+	return fh, nil
+}
+
+// MultipartForm 解析并返回完整的 multipart 表单，包括文件。
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	err := c.Req.ParseMultipartForm(c.maxMultipartMemory())
+	return c.Req.MultipartForm, err
+}
+
+// SaveUploadedFile 把上传文件保存到 dst，必要时创建父目录。
+func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, src)
+	return err
+}
+
+// parseForm 按 Content-Type 解析普通表单或 multipart 表单，重复调用无额外开销。
+func (c *Context) parseForm() error {
+	if isMultipart(c.Req) {
+		if c.Req.MultipartForm != nil {
+			return nil
+		}
+		if err := c.Req.ParseMultipartForm(c.maxMultipartMemory()); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return err
+		}
+		return nil
+	}
+	return c.Req.ParseForm()
+}
+
+func (c *Context) maxMultipartMemory() int64 {
+	if c.engine != nil && c.engine.MaxMultipartMemory > 0 {
+		return c.engine.MaxMultipartMemory
+	}
+	return defaultMultipartMemory
+}
+
+func isMultipart(req *http.Request) bool {
+	ct, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	return ct == "multipart/form-data"
+}
+
+// bracketMap 从 values 中挑出 key[sub] 形式的条目，返回 sub -> 第一个值。
+func bracketMap(values map[string][]string, key string) map[string]string {
+	out := make(map[string]string)
+	prefix := key + "["
+	for k, v := range values {
+		if !strings.HasPrefix(k, prefix) || !strings.HasSuffix(k, "]") || len(v) == 0 {
+			continue
+		}
+		out[k[len(prefix):len(k)-1]] = v[0]
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package tinygee
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestQueryHelpers(t *testing.T) {
+	engine := New()
+	engine.GET("/search", func(c *Context) {
+		ids := c.QueryArray("id")
+		filter := c.QueryMap("filter")
+		c.String(http.StatusOK, "%s|%s|%s|%v|%s,%s",
+			c.Query("q"), c.DefaultQuery("page", "1"), c.DefaultQuery("empty", "x"),
+			ids, filter["status"], filter["owner"])
+	})
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/search?q=go&empty=&id=1&id=2&filter[status]=done&filter[owner]=me", nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if want := "go|1||[1 2]|done,me"; w.Body.String() != want {
+		t.Fatalf("want %q got %q", want, w.Body.String())
+	}
+}
+
+func TestPostForm(t *testing.T) {
+	engine := New()
+	engine.POST("/login", func(c *Context) {
+		c.String(http.StatusOK, "%s|%s|%s", c.PostForm("user"), c.DefaultPostForm("remember", "no"), c.PostForm("q"))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/login?q=query-only", strings.NewReader("user=ann"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if want := "ann|no|"; w.Body.String() != want {
+		t.Fatalf("want %q got %q", want, w.Body.String())
+	}
+}
+
+func TestUploadFile(t *testing.T) {
+	dir := t.TempDir()
+	engine := New()
+	engine.MaxMultipartMemory = 1 << 10
+	engine.POST("/upload", func(c *Context) {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.String(http.StatusBadRequest, "%v", err)
+			return
+		}
+		form, err := c.MultipartForm()
+		if err != nil {
+			c.String(http.StatusBadRequest, "%v", err)
+			return
+		}
+		dst := filepath.Join(dir, "nested", file.Filename)
+		if err := c.SaveUploadedFile(file, dst); err != nil {
+			c.String(http.StatusInternalServerError, "%v", err)
+			return
+		}
+		c.String(http.StatusOK, "%s %d %s", file.Filename, len(form.File["file"]), c.PostForm("note"))
+	})
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("note", "hi")
+	fw, _ := mw.CreateFormFile("file", "a.txt")
+	_, _ = fw.Write(bytes.Repeat([]byte("x"), 4<<10)) // 超过内存上限，落盘到临时文件
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "a.txt 1 hi" {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "nested", "a.txt"))
+	if err != nil || len(data) != 4<<10 {
+		t.Fatalf("saved file mismatch: %v len=%d", err, len(data))
+	}
+}
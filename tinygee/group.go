@@ -3,42 +3,80 @@ package tinygee
 import "net/http"
 
 // RouterGroup 支持路由分组与分组中间件。
+// 分组中间件绑定在分组上：路由只会经过注册它的分组及其祖先分组的中间件。
 type RouterGroup struct {
+	host        string // 非空时只匹配该虚拟主机的请求
 	prefix      string
 	middlewares []HandlerFunc
+	parent      *RouterGroup
 	engine      *Engine
 }
 
-// Group 创建子分组。
+// Group 创建子分组，子分组继承当前分组的中间件（包括之后再 Use 的）。
 func (g *RouterGroup) Group(prefix string) *RouterGroup {
-	newGroup := &RouterGroup{
+	return &RouterGroup{
+		host:   g.host,
 		prefix: g.prefix + prefix,
+		parent: g,
 		engine: g.engine,
 	}
-	g.engine.groups = append(g.engine.groups, newGroup)
-	return newGroup
 }
 
 // Use 为分组注册中间件。
 func (g *RouterGroup) Use(m ...HandlerFunc) {
 	g.middlewares = append(g.middlewares, m...)
+	g.engine.rebuildHandlers()
 }
 
 // addRoute 带分组前缀的路由注册。
-func (g *RouterGroup) addRoute(method, comp string, handler HandlerFunc) {
+func (g *RouterGroup) addRoute(method, comp string, handlers []HandlerFunc) *Route {
 	pattern := g.prefix + comp
-	g.engine.router.addRoute(method, pattern, handler)
+	return g.engine.addRoute(g, method, pattern, handlers)
 }
 
-func (g *RouterGroup) GET(pattern string, handler HandlerFunc) {
-	g.addRoute(http.MethodGet, pattern, handler)
+// Handle 注册任意方法的分组路由；handlers 中最后一个是业务处理器，前面的是路由级中间件。
+func (g *RouterGroup) Handle(method, pattern string, handlers ...HandlerFunc) *Route {
+	return g.addRoute(method, pattern, handlers)
 }
 
-func (g *RouterGroup) POST(pattern string, handler HandlerFunc) {
-	g.addRoute(http.MethodPost, pattern, handler)
+func (g *RouterGroup) GET(pattern string, handlers ...HandlerFunc) *Route {
+	return g.addRoute(http.MethodGet, pattern, handlers)
+}
+
+func (g *RouterGroup) POST(pattern string, handlers ...HandlerFunc) *Route {
+	return g.addRoute(http.MethodPost, pattern, handlers)
+}
+
+func (g *RouterGroup) PUT(pattern string, handlers ...HandlerFunc) *Route {
+	return g.addRoute(http.MethodPut, pattern, handlers)
+}
+
+func (g *RouterGroup) PATCH(pattern string, handlers ...HandlerFunc) *Route {
+	return g.addRoute(http.MethodPatch, pattern, handlers)
+}
+
+func (g *RouterGroup) DELETE(pattern string, handlers ...HandlerFunc) *Route {
+	return g.addRoute(http.MethodDelete, pattern, handlers)
+}
+
+func (g *RouterGroup) OPTIONS(pattern string, handlers ...HandlerFunc) *Route {
+	return g.addRoute(http.MethodOptions, pattern, handlers)
+}
+
+func (g *RouterGroup) HEAD(pattern string, handlers ...HandlerFunc) *Route {
+	return g.addRoute(http.MethodHead, pattern, handlers)
+}
+
+// Any 为 anyMethods 中的所有方法注册同一组分组处理器。
+func (g *RouterGroup) Any(pattern string, handlers ...HandlerFunc) *Route {
+	r := &Route{engine: g.engine}
+	for _, method := range anyMethods {
+		r.routes = append(r.routes, g.addRoute(method, pattern, handlers).routes...)
+	}
+	return r
 }
 
 // Engine 的 Group 代理方法
 func (e *Engine) Group(prefix string) *RouterGroup {
-	return e.groups[0].Group(prefix)
+	return e.root.Group(prefix)
 }
@@ -0,0 +1,77 @@
+package tinygee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// trace 返回一个记录名字的中间件，用于断言执行顺序。
+func trace(log *[]string, name string) HandlerFunc {
+	return func(c *Context) {
+		*log = append(*log, name)
+		c.Next()
+	}
+}
+
+func TestMiddlewareBoundToGroup(t *testing.T) {
+	r := New()
+	var got []string
+	r.Use(trace(&got, "global"))
+	api := r.Group("/api")
+	api.Use(trace(&got, "api"))
+	apiv2 := r.Group("/apiv2")
+	apiv2.Use(trace(&got, "apiv2"))
+	admin := api.Group("/admin")
+
+	ok := func(c *Context) { got = append(got, "handler") }
+	api.GET("/users", ok)
+	apiv2.GET("/users", ok)
+	admin.GET("/stats", trace(&got, "route"), ok)
+	// 直接挂在根上的 /api/health 不属于 api 分组，不应经过其中间件
+	r.GET("/api/health", ok)
+	// 子分组之后再 Use 的父分组中间件同样生效
+	api.Use(trace(&got, "api-late"))
+
+	cases := map[string]string{
+		"/api/users":       "global,api,api-late,handler",
+		"/apiv2/users":     "global,apiv2,handler",
+		"/api/admin/stats": "global,api,api-late,route,handler",
+		"/api/health":      "global,handler",
+	}
+	for path, want := range cases {
+		got = got[:0]
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if s := strings.Join(got, ","); s != want {
+			t.Errorf("%s: got %s, want %s", path, s, want)
+		}
+	}
+}
+
+func TestRouteLevelMiddlewareAbort(t *testing.T) {
+	r := New()
+	deny := func(c *Context) { c.AbortWithStatusJSON(http.StatusForbidden, map[string]string{"error": "forbidden"}) }
+	called := false
+	r.DELETE("/items/:id", deny, func(c *Context) { called = true })
+	r.GET("/items/:id", func(c *Context) { c.String(http.StatusOK, "item") })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/items/1", nil))
+	if w.Code != http.StatusForbidden || called {
+		t.Fatalf("route middleware should abort: %d called=%v", w.Code, called)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("sibling method should not be affected: %d", w.Code)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering without handlers should panic")
+		}
+	}()
+	r.GET("/empty")
+}
@@ -0,0 +1,57 @@
+package tinygee
+
+import (
+	"net"
+	"strings"
+)
+
+// Host 返回只匹配指定虚拟主机的分组，支持 "*.example.com" 匹配任意子域名。
+// 命中虚拟主机的请求只在该主机的路由中查找；其余请求走默认路由。
+// 全局中间件对所有主机生效，分组中间件只作用于同一主机下的路由。
+func (e *Engine) Host(host string) *RouterGroup {
+	host = strings.ToLower(host)
+	if host == "" {
+		panic("tinygee: host must not be empty")
+	}
+	e.routerFor(host)
+	return &RouterGroup{host: host, engine: e}
+}
+
+// routerFor 返回 host 对应的路由树，不存在时创建。
+func (e *Engine) routerFor(host string) *router {
+	if host == "" {
+		return e.router
+	}
+	for _, r := range e.hosts {
+		if r.host == host {
+			return r
+		}
+	}
+	r := newRouter()
+	r.host = host
+	e.hosts = append(e.hosts, r)
+	return r
+}
+
+// matchHost 按请求的 Host 头选择虚拟主机路由，精确匹配优先于通配。
+func (e *Engine) matchHost(reqHost string) *router {
+	host := strings.ToLower(stripPort(reqHost))
+	var wildcard *router
+	for _, r := range e.hosts {
+		if r.host == host {
+			return r
+		}
+		if wildcard == nil && strings.HasPrefix(r.host, "*.") &&
+			strings.HasSuffix(host, r.host[1:]) && len(host) > len(r.host)-1 {
+			wildcard = r
+		}
+	}
+	return wildcard
+}
+
+func stripPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
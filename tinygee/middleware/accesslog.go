@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+// LogField 是访问日志可输出的字段。
+type LogField string
+
+const (
+	FieldMethod    LogField = "method"
+	FieldRoute     LogField = "route"
+	FieldPath      LogField = "path"
+	FieldStatus    LogField = "status"
+	FieldBytes     LogField = "bytes"
+	FieldLatency   LogField = "latency"
+	FieldClientIP  LogField = "client_ip"
+	FieldUserID    LogField = "user_id"
+	FieldRequestID LogField = "request_id"
+)
+
+// DefaultLogFields 是未配置 Fields 时输出的字段。
+var DefaultLogFields = []LogField{
+	FieldMethod, FieldRoute, FieldPath, FieldStatus, FieldBytes,
+	FieldLatency, FieldClientIP, FieldUserID, FieldRequestID,
+}
+
+// AccessLogConfig 配置结构化访问日志。
+type AccessLogConfig struct {
+	// Logger 为 nil 时使用 slog.Default()
+	Logger *slog.Logger
+	// Fields 决定输出哪些字段及顺序，默认 DefaultLogFields
+	Fields []LogField
+	// SkipPaths 中的请求路径不记录，常用于健康检查
+	SkipPaths []string
+	// SampleRate 取 (0,1) 时按比例抽样记录，5xx 与带错误的请求始终记录；其余值记录全部
+	SampleRate float64
+	// UserIDKey 是 Keys 中用户 ID 的键，默认与 auth.UserIDKey 一致
+	UserIDKey string
+}
+
+// AccessLogger 基于 slog 记录访问日志：2xx/3xx 为 Info，4xx 为 Warn，5xx 为 Error。
+// route 字段是命中的路由模式（见 Context.FullPath），未命中时为空串。
+func AccessLogger(cfgs ...AccessLogConfig) tinygee.HandlerFunc {
+	cfg := AccessLogConfig{}
+	if len(cfgs) > 0 {
+		cfg = cfgs[0]
+	}
+	if cfg.Fields == nil {
+		cfg.Fields = DefaultLogFields
+	}
+	if cfg.UserIDKey == "" {
+		cfg.UserIDKey = "uid"
+	}
+	skip := make(map[string]struct{}, len(cfg.SkipPaths))
+	for _, p := range cfg.SkipPaths {
+		skip[p] = struct{}{}
+	}
+
+	return func(c *tinygee.Context) {
+		if _, ok := skip[c.Path]; ok {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
+
+		status := c.Writer.Status()
+		if status < http.StatusInternalServerError && len(c.Errors) == 0 &&
+			cfg.SampleRate > 0 && cfg.SampleRate < 1 && rand.Float64() >= cfg.SampleRate {
+			return
+		}
+		logger := cfg.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := make([]slog.Attr, 0, len(cfg.Fields)+1)
+		for _, f := range cfg.Fields {
+			key := string(f)
+			switch f {
+			case FieldMethod:
+				attrs = append(attrs, slog.String(key, c.Method))
+			case FieldRoute:
+				attrs = append(attrs, slog.String(key, c.FullPath()))
+			case FieldPath:
+				attrs = append(attrs, slog.String(key, c.Path))
+			case FieldStatus:
+				attrs = append(attrs, slog.Int(key, status))
+			case FieldBytes:
+				attrs = append(attrs, slog.Int(key, max(c.Writer.Size(), 0)))
+			case FieldLatency:
+				attrs = append(attrs, slog.Duration(key, latency))
+			case FieldClientIP:
+				attrs = append(attrs, slog.String(key, c.ClientIP()))
+			case FieldUserID:
+				if uid, ok := c.Get(cfg.UserIDKey); ok {
+					attrs = append(attrs, slog.Any(key, uid))
+				}
+			case FieldRequestID:
+				if id := c.RequestID(); id != "" {
+					attrs = append(attrs, slog.String(key, id))
+				}
+			}
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", strings.TrimSpace(c.Errors.String())))
+		}
+		logger.LogAttrs(c.Req.Context(), level, "access", attrs...)
+	}
+}
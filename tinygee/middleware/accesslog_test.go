@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("bad log line %q: %v", line, err)
+		}
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestAccessLogger(t *testing.T) {
+	var buf bytes.Buffer
+	app := tinygee.New()
+	app.Use(RequestID(), AccessLogger(AccessLogConfig{
+		Logger:    slog.New(slog.NewJSONHandler(&buf, nil)),
+		SkipPaths: []string{"/healthz"},
+	}))
+	app.GET("/healthz", func(c *tinygee.Context) { c.String(http.StatusOK, "ok") })
+	app.GET("/users/:id", func(c *tinygee.Context) {
+		c.Set("uid", 7)
+		c.String(http.StatusOK, "user")
+	})
+	app.GET("/fail", func(c *tinygee.Context) {
+		c.AbortWithError(http.StatusServiceUnavailable, errors.New("db down"))
+	})
+
+	for _, p := range []string{"/healthz", "/users/42", "/fail"} {
+		req := httptest.NewRequest(http.MethodGet, p, nil)
+		req.Header.Set("X-Request-ID", "req-"+strings.Trim(p, "/"))
+		app.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	lines := decodeLogLines(t, &buf)
+	if len(lines) != 2 {
+		t.Fatalf("want 2 lines (healthz skipped), got %d: %s", len(lines), buf.String())
+	}
+	user := lines[0]
+	want := map[string]any{
+		"level": "INFO", "msg": "access", "method": "GET", "route": "/users/:id", "path": "/users/42",
+		"status": float64(200), "bytes": float64(4), "client_ip": "192.0.2.1", "user_id": float64(7),
+		"request_id": "req-users/42",
+	}
+	// 含 / 的请求 ID 不合法，应被替换
+	if user["request_id"] == want["request_id"] {
+		t.Fatalf("invalid request id should be replaced")
+	}
+	delete(want, "request_id")
+	for k, v := range want {
+		if user[k] != v {
+			t.Fatalf("field %s: got %v want %v", k, user[k], v)
+		}
+	}
+	if _, ok := user["latency"]; !ok {
+		t.Fatalf("missing latency: %v", user)
+	}
+	fail := lines[1]
+	if fail["level"] != "ERROR" || fail["request_id"] != "req-fail" || !strings.Contains(fail["errors"].(string), "db down") {
+		t.Fatalf("unexpected failure line: %v", fail)
+	}
+}
+
+func TestAccessLoggerFieldsAndSampling(t *testing.T) {
+	var buf bytes.Buffer
+	app := tinygee.New()
+	app.Use(AccessLogger(AccessLogConfig{
+		Logger:     slog.New(slog.NewJSONHandler(&buf, nil)),
+		Fields:     []LogField{FieldRoute, FieldStatus},
+		SampleRate: 1e-12,
+	}))
+	app.GET("/ok", func(c *tinygee.Context) { c.Status(http.StatusNoContent) })
+	app.GET("/boom", func(c *tinygee.Context) { c.Status(http.StatusInternalServerError) })
+
+	for i := 0; i < 20; i++ {
+		app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	}
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/boom", nil))
+
+	lines := decodeLogLines(t, &buf)
+	if len(lines) != 1 {
+		t.Fatalf("only the 5xx should survive sampling, got %s", buf.String())
+	}
+	if len(lines[0]) != 5 || lines[0]["route"] != "/boom" {
+		t.Fatalf("want time/level/msg + 2 fields, got %v", lines[0])
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	app := tinygee.New()
+	app.Use(RequestID())
+	var seen string
+	app.GET("/", func(c *tinygee.Context) { seen = c.RequestID() })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	if seen != "abc-123" || w.Header().Get("X-Request-ID") != "abc-123" {
+		t.Fatalf("incoming id not honoured: ctx %q header %q", seen, w.Header().Get("X-Request-ID"))
+	}
+
+	for _, incoming := range []string{"", "bad\nid", strings.Repeat("a", 129)} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-ID", incoming)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		if len(seen) != 32 || w.Header().Get("X-Request-ID") != seen {
+			t.Fatalf("%q: want generated id, got ctx %q header %q", incoming, seen, w.Header().Get("X-Request-ID"))
+		}
+	}
+}
@@ -16,6 +16,13 @@ type JWTConfig struct {
 	TTL    time.Duration
 }
 
+// Context 中保存用户信息的键。
+const (
+	UserIDKey = "uid" // 字符串形式的用户 ID（即 Subject）
+	RoleKey   = "role"
+	ClaimsKey = "claims" // 完整的 *Claims
+)
+
 // Claims 自定义声明
 type Claims struct {
 	UserID uint   `json:"uid"`
@@ -28,7 +35,7 @@ func NewJWTMiddleware(cfg JWTConfig) tinygee.HandlerFunc {
 	return func(c *tinygee.Context) {
 		authHeader := c.Req.Header.Get("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, map[string]string{"error": "authorization required"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{"error": "authorization required"})
 			return
 		}
 		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
@@ -37,19 +44,35 @@ func NewJWTMiddleware(cfg JWTConfig) tinygee.HandlerFunc {
 			return []byte(cfg.Secret), nil
 		})
 		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired token"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired token"})
 			return
 		}
-		// 将用户信息放入 Context
-		if c.Params == nil {
-			c.Params = map[string]string{}
-		}
-		c.Params["uid"] = claims.Subject
-		c.Params["role"] = claims.Role
+		// 将用户信息放入 Context 的键值存储，不占用路由参数
+		c.Set(UserIDKey, claims.Subject)
+		c.Set(RoleKey, claims.Role)
+		c.Set(ClaimsKey, claims)
 		c.Next()
 	}
 }
 
+// UserID 读取 JWT 中间件写入的数值型用户 ID。
+func UserID(c *tinygee.Context) (uint, bool) {
+	v, ok := c.Get(ClaimsKey)
+	if !ok {
+		return 0, false
+	}
+	claims, ok := v.(*Claims)
+	if !ok {
+		return 0, false
+	}
+	return claims.UserID, true
+}
+
+// Role 读取 JWT 中间件写入的角色。
+func Role(c *tinygee.Context) string {
+	return c.GetString(RoleKey)
+}
+
 // GenerateToken 签发 token（示例用途）
 func GenerateToken(cfg JWTConfig, uid uint, role string) (string, error) {
 	claims := Claims{
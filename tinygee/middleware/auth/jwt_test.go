@@ -18,12 +18,24 @@ func TestJWTMiddleware(t *testing.T) {
 
 	engine := tinygee.New()
 	engine.Use(NewJWTMiddleware(cfg))
-	engine.GET("/ping", func(c *tinygee.Context) {
+	engine.GET("/ping/:uid", func(c *tinygee.Context) {
+		uid, ok := UserID(c)
+		if !ok || uid != 1 || Role(c) != "admin" {
+			t.Errorf("unexpected identity uid=%d role=%q", uid, Role(c))
+		}
+		// "uid" 键保持字符串类型，与旧版 Param("uid") 的取值一致
+		if got := c.GetString(UserIDKey); got != "1" {
+			t.Errorf("uid key = %q, want \"1\"", got)
+		}
+		// 路由参数不应被 JWT 中间件覆盖
+		if c.Param("uid") != "me" {
+			t.Errorf("route param overwritten: %q", c.Param("uid"))
+		}
 		c.String(http.StatusOK, "ok")
 	})
 
 	// valid
-	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req := httptest.NewRequest(http.MethodGet, "/ping/me", nil)
 	req.Header.Set("Authorization", "Bearer "+token)
 	w := httptest.NewRecorder()
 	engine.ServeHTTP(w, req)
@@ -33,7 +45,7 @@ func TestJWTMiddleware(t *testing.T) {
 
 	// expired
 	time.Sleep(time.Second)
-	req2 := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req2 := httptest.NewRequest(http.MethodGet, "/ping/me", nil)
 	req2.Header.Set("Authorization", "Bearer "+token)
 	w2 := httptest.NewRecorder()
 	engine.ServeHTTP(w2, req2)
@@ -14,9 +14,9 @@ type RBACConfig struct {
 // RBAC 返回授权中间件。
 func RBAC(cfg RBACConfig) tinygee.HandlerFunc {
 	return func(c *tinygee.Context) {
-		role := c.Params["role"]
+		role := Role(c)
 		if role == "" {
-			c.JSON(http.StatusForbidden, map[string]string{"error": "role required"})
+			c.AbortWithStatusJSON(http.StatusForbidden, map[string]string{"error": "role required"})
 			return
 		}
 		prefixes := cfg.RolePermissions[role]
@@ -26,6 +26,6 @@ func RBAC(cfg RBACConfig) tinygee.HandlerFunc {
 				return
 			}
 		}
-		c.JSON(http.StatusForbidden, map[string]string{"error": "insufficient permissions"})
+		c.AbortWithStatusJSON(http.StatusForbidden, map[string]string{"error": "insufficient permissions"})
 	}
 }
@@ -11,7 +11,7 @@ import (
 func TestRBACReject(t *testing.T) {
 	app := tinygee.New()
 	// 模拟 JWT 中写入 role
-	app.Use(func(c *tinygee.Context) { c.Params = map[string]string{"role": "user"}; c.Next() })
+	app.Use(func(c *tinygee.Context) { c.Set(RoleKey, "user"); c.Next() })
 	app.Use(RBAC(RBACConfig{
 		RolePermissions: map[string][]string{
 			"admin": {"/api"},
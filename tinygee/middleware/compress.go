@@ -0,0 +1,264 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+// CompressConfig 配置响应压缩。
+type CompressConfig struct {
+	// Level 是压缩级别，取值同 compress/flate，0 表示 flate.DefaultCompression
+	Level int
+	// MinLength 小于该字节数的响应不压缩，默认 1024；Flush 时不足也会开始压缩
+	MinLength int
+	// ExcludedContentTypes 按前缀匹配不压缩的类型，默认为常见的已压缩格式
+	ExcludedContentTypes []string
+}
+
+// DefaultExcludedContentTypes 是本身已经压缩、再压缩没有收益的类型。
+var DefaultExcludedContentTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip",
+	"application/zstd", "application/x-7z-compressed", "application/x-rar-compressed",
+}
+
+// encoder 是 gzip.Writer 与 zlib.Writer 的公共方法。
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+// Compress 按 Accept-Encoding 协商 gzip 或 deflate 压缩响应体。
+// 响应体先缓冲到 MinLength 再决定是否压缩；已设置 Content-Encoding、206 与空响应体保持原样。
+// HEAD 请求按同样规则（没有响应体时按声明的 Content-Length）设置 Content-Encoding、Vary 等响应头，
+// 但丢弃响应体，与 GET 的响应头保持一致。可能被压缩的响应总是带 Vary: Accept-Encoding。
+// SSE 等调用 Flush 的响应会立即压缩并逐块推送。
+func Compress(cfgs ...CompressConfig) tinygee.HandlerFunc {
+	cfg := CompressConfig{}
+	if len(cfgs) > 0 {
+		cfg = cfgs[0]
+	}
+	if cfg.Level == 0 {
+		cfg.Level = gzip.DefaultCompression
+	}
+	if cfg.MinLength <= 0 {
+		cfg.MinLength = 1024
+	}
+	if cfg.ExcludedContentTypes == nil {
+		cfg.ExcludedContentTypes = DefaultExcludedContentTypes
+	}
+	if _, err := gzip.NewWriterLevel(io.Discard, cfg.Level); err != nil {
+		panic("tinygee: invalid compress level " + strconv.Itoa(cfg.Level))
+	}
+	pools := map[string]*sync.Pool{
+		"gzip": {New: func() any {
+			w, _ := gzip.NewWriterLevel(io.Discard, cfg.Level)
+			return w
+		}},
+		"deflate": {New: func() any {
+			w, _ := zlib.NewWriterLevel(io.Discard, cfg.Level)
+			return w
+		}},
+	}
+
+	return func(c *tinygee.Context) {
+		// Upgrade 请求（WebSocket 等）可能被 Hijack，不包装 Writer；同一 URL 的普通请求可能被压缩，仍声明 Vary
+		if c.Req.Header.Get("Upgrade") != "" {
+			addVary(c.Writer.Header(), "Accept-Encoding")
+			c.Next()
+			return
+		}
+		// 客户端不接受 gzip/deflate 时 coding 为空：照样判断响应能否压缩以声明 Vary，但不压缩也不缓冲
+		coding := negotiateEncoding(c.Req.Header.Get("Accept-Encoding"))
+		cw := &compressWriter{ResponseWriter: c.Writer, cfg: &cfg, coding: coding, pool: pools[coding], head: c.Method == http.MethodHead}
+		c.Writer = cw
+		defer func() { c.Writer = cw.ResponseWriter }()
+		c.Next()
+		cw.close()
+	}
+}
+
+// negotiateEncoding 从 Accept-Encoding 中选出 q 值最高的 gzip 或 deflate，相同时优先 gzip。
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if coding == "*" {
+			coding = "gzip"
+		}
+		if (coding != "gzip" && coding != "deflate") || q <= 0 {
+			continue
+		}
+		if q > bestQ || q == bestQ && coding == "gzip" {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// compressWriter 缓冲响应体直到可以判断是否压缩；decided 之后要么经 enc 压缩写出，要么直通。
+// HEAD 请求决定压缩后只设置响应头，discard 为 true 时丢弃响应体；coding 为空时只声明 Vary，不缓冲。
+type compressWriter struct {
+	tinygee.ResponseWriter
+	cfg     *CompressConfig
+	coding  string
+	pool    *sync.Pool
+	head    bool
+	buf     []byte
+	decided bool
+	discard bool
+	enc     encoder
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, data...)
+		if w.coding != "" && len(w.buf) < w.cfg.MinLength {
+			return len(data), nil
+		}
+		if err := w.decide(false); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.discard {
+		return len(data), nil
+	}
+	if w.enc != nil {
+		return w.enc.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written 在响应体已缓冲但尚未发出时也返回 true，避免后续 handler 误以为还能改写响应。
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Size() int {
+	if !w.decided && len(w.buf) > 0 {
+		return len(w.buf)
+	}
+	return w.ResponseWriter.Size()
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Flush 不再等待 MinLength，直接决定压缩与否并把已压缩的数据推给客户端。
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(true)
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide 根据状态码、已有响应头与内容类型决定是否压缩，并写出已缓冲的数据。
+func (w *compressWriter) decide(flushing bool) error {
+	w.decided = true
+	buf := w.buf
+	w.buf = nil
+	if w.shouldCompress(buf, flushing) {
+		h := w.Header()
+		h.Set("Content-Encoding", w.coding)
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+		// 压缩后字节不同，强 ETag 降为弱 ETag
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		if w.head {
+			w.discard = true
+			return nil
+		}
+		w.enc = w.pool.Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.enc != nil {
+		_, err := w.enc.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func (w *compressWriter) shouldCompress(buf []byte, flushing bool) bool {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || w.Status() == http.StatusPartialContent {
+		return false
+	}
+	if w.Status() < http.StatusOK || w.Status() == http.StatusNoContent || w.Status() == http.StatusNotModified {
+		return false
+	}
+	ct := h.Get("Content-Type")
+	if ct == "" && len(buf) > 0 {
+		ct = http.DetectContentType(buf)
+		h.Set("Content-Type", ct)
+	}
+	for _, prefix := range w.cfg.ExcludedContentTypes {
+		if strings.HasPrefix(ct, prefix) {
+			return false
+		}
+	}
+	// 响应本可以被压缩，不论这次是否压缩都要声明 Vary，避免共享缓存把未压缩版本发给所有客户端
+	addVary(h, "Accept-Encoding")
+	if w.coding == "" {
+		return false
+	}
+	if flushing {
+		return true
+	}
+	n := len(buf)
+	if w.head && n == 0 {
+		// HEAD 通常不写响应体（如 http.ServeContent），按声明的 Content-Length 判断，与 GET 的结果一致
+		if cl, err := strconv.Atoi(h.Get("Content-Length")); err == nil {
+			n = cl
+		}
+	}
+	return n >= w.cfg.MinLength
+}
+
+// close 在 handler 链结束后写出剩余缓冲并结束压缩流，encoder 放回池中复用。
+func (w *compressWriter) close() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+		w.enc.Reset(io.Discard)
+		w.pool.Put(w.enc)
+		w.enc = nil
+	}
+}
@@ -0,0 +1,238 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+	"github.com/xrjjing/Learn4Go/tinygee/render"
+)
+
+func newCompressApp() *tinygee.Engine {
+	app := tinygee.New()
+	app.Use(Compress())
+	app.GET("/list", func(c *tinygee.Context) {
+		items := make([]map[string]any, 100)
+		for i := range items {
+			items[i] = map[string]any{"id": i, "title": "buy milk", "done": false}
+		}
+		c.JSON(http.StatusOK, items)
+	})
+	app.GET("/small", func(c *tinygee.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	app.GET("/image", func(c *tinygee.Context) {
+		c.SetHeader("Content-Type", "image/png")
+		c.Data(http.StatusOK, make([]byte, 4096))
+	})
+	return app
+}
+
+func getWithEncoding(app http.Handler, method, path, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if accept != "" {
+		req.Header.Set("Accept-Encoding", accept)
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w
+}
+
+func TestCompressNegotiation(t *testing.T) {
+	app := newCompressApp()
+	plain := getWithEncoding(app, http.MethodGet, "/list", "").Body.String()
+
+	w := getWithEncoding(app, http.MethodGet, "/list", "gzip, deflate")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("want gzip with Vary, got %v", w.Header())
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(zr)
+	if string(body) != plain || w.Body.Len() >= len(plain) {
+		t.Fatalf("gzip body mismatch or not smaller")
+	}
+
+	w = getWithEncoding(app, http.MethodGet, "/list", "gzip;q=0.5, deflate")
+	if w.Header().Get("Content-Encoding") != "deflate" {
+		t.Fatalf("want deflate, got %v", w.Header())
+	}
+	dr, err := zlib.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(dr); string(body) != plain {
+		t.Fatalf("deflate body mismatch")
+	}
+
+	for _, tc := range []struct{ method, path, accept string }{
+		{http.MethodGet, "/list", "br"},
+		{http.MethodGet, "/list", "gzip;q=0"},
+		{http.MethodGet, "/small", "gzip"},
+		{http.MethodGet, "/image", "gzip"},
+		{http.MethodHead, "/small", "gzip"},
+	} {
+		w := getWithEncoding(app, tc.method, tc.path, tc.accept)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "" {
+			t.Fatalf("%s %s %q should not be compressed: %d %v", tc.method, tc.path, tc.accept, w.Code, w.Header())
+		}
+	}
+	if w := getWithEncoding(app, http.MethodGet, "/small", "gzip"); w.Body.String() != "ok" {
+		t.Fatalf("small body %q", w.Body.String())
+	}
+}
+
+func TestCompressHeadMatchesGet(t *testing.T) {
+	app := tinygee.New()
+	app.Use(Compress())
+	app.GET("/list", func(c *tinygee.Context) {
+		c.SetHeader("ETag", `"v1"`)
+		c.String(http.StatusOK, "%s", strings.Repeat("buy milk ", 200))
+	})
+
+	// 用真实的 http.Server，确认 HEAD 不会因为写入未压缩的响应体而带上 Content-Length
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+	do := func(method string) *http.Response {
+		req, _ := http.NewRequest(method, srv.URL+"/list", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	get, head := do(http.MethodGet), do(http.MethodHead)
+	for _, k := range []string{"Content-Encoding", "Vary", "ETag"} {
+		if get.Header.Get(k) != head.Header.Get(k) {
+			t.Errorf("%s: GET %q, HEAD %q", k, get.Header.Get(k), head.Header.Get(k))
+		}
+	}
+	// 压缩后的长度只有真正压缩才知道，HEAD 宁可不带也不能报未压缩的长度
+	if head.Header.Get("Content-Encoding") != "gzip" || head.Header.Get("Content-Length") != "" {
+		t.Fatalf("HEAD headers: %v", head.Header)
+	}
+}
+
+// StaticFS 经 http.ServeContent 处理 HEAD，不写响应体，只声明 Content-Length
+func TestCompressHeadMatchesGetStaticFS(t *testing.T) {
+	app := tinygee.New()
+	app.Use(Compress())
+	app.GET("/static/*filepath", render.StaticFS(render.StaticConfig{
+		Root:        fstest.MapFS{"app.js": {Data: []byte(strings.Repeat("console.log(1);\n", 500))}},
+		StripPrefix: "/static",
+	}))
+
+	for _, accept := range []string{"gzip", ""} {
+		get := getWithEncoding(app, http.MethodGet, "/static/app.js", accept)
+		head := getWithEncoding(app, http.MethodHead, "/static/app.js", accept)
+		if get.Code != http.StatusOK || head.Code != http.StatusOK {
+			t.Fatalf("%q: GET %d HEAD %d", accept, get.Code, head.Code)
+		}
+		for _, k := range []string{"Content-Encoding", "Content-Length", "Content-Type", "ETag", "Vary", "Accept-Ranges"} {
+			if get.Header().Get(k) != head.Header().Get(k) {
+				t.Errorf("%q %s: GET %q, HEAD %q", accept, k, get.Header().Get(k), head.Header().Get(k))
+			}
+		}
+		if head.Body.Len() != 0 {
+			t.Errorf("%q: HEAD body %d bytes", accept, head.Body.Len())
+		}
+	}
+	if w := getWithEncoding(app, http.MethodHead, "/static/app.js", "gzip"); w.Header().Get("Content-Encoding") != "gzip" || !strings.HasPrefix(w.Header().Get("ETag"), "W/") {
+		t.Fatalf("HEAD should report the compressed representation: %v", w.Header())
+	}
+}
+
+func TestCompressVaryWithoutCompression(t *testing.T) {
+	app := newCompressApp()
+	for _, tc := range []struct {
+		name, path, accept string
+		header             []string
+		vary               bool
+	}{
+		{"no accept-encoding", "/list", "", nil, true},
+		{"identity only", "/list", "br", nil, true},
+		{"below min length", "/small", "gzip", nil, true},
+		{"upgrade", "/list", "gzip", []string{"Upgrade", "websocket"}, true},
+		{"excluded type", "/image", "gzip", nil, false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.accept != "" {
+			req.Header.Set("Accept-Encoding", tc.accept)
+		}
+		if tc.header != nil {
+			req.Header.Set(tc.header[0], tc.header[1])
+		}
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		if w.Header().Get("Content-Encoding") != "" {
+			t.Fatalf("%s: should not be compressed", tc.name)
+		}
+		if got := w.Header().Values("Vary"); tc.vary != (len(got) == 1 && got[0] == "Accept-Encoding") {
+			t.Errorf("%s: Vary = %q", tc.name, got)
+		}
+	}
+}
+
+func TestCompressSSEFlush(t *testing.T) {
+	release := make(chan struct{})
+	app := tinygee.New()
+	app.Use(Logger(), Compress())
+	app.GET("/events", func(c *tinygee.Context) {
+		c.SSEvent("ping", "1")
+		<-release
+		c.SSEvent("ping", "2")
+	})
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/events", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("want gzip event stream, got %v", resp.Header)
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := bufio.NewReader(zr).ReadString('\n')
+	close(release)
+	if err != nil || first != "event: ping\n" {
+		t.Fatalf("first event not flushed through gzip: %q %v", first, err)
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	cases := map[string]string{
+		"":                        "",
+		"identity":                "",
+		"*":                       "gzip",
+		"deflate, gzip":           "gzip",
+		"deflate;q=1, gzip;q=0.9": "deflate",
+		"GZIP;q=0.1":              "gzip",
+		"gzip;q=abc":              "",
+	}
+	for header, want := range cases {
+		if got := negotiateEncoding(header); got != want {
+			t.Fatalf("%q: got %q want %q", header, got, want)
+		}
+	}
+	if !strings.Contains(strings.Join(DefaultExcludedContentTypes, ","), "image/png") {
+		t.Fatal("png should be excluded by default")
+	}
+}
@@ -0,0 +1,255 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+// CORSConfig 配置跨域资源共享。
+type CORSConfig struct {
+	// AllowOrigins 是允许的来源，按小写比较：精确值如 https://app.example.com，
+	// 或含一个 * 的通配如 https://*.example.com、http://localhost:*；单独的 "*" 允许任意来源。
+	// 与 AllowOriginPatterns、AllowOriginFunc 都为空时默认为 "*"
+	AllowOrigins []string
+	// AllowOriginPatterns 是匹配完整 Origin 的正则，如 ^https://pr-\d+\.preview\.example\.com$
+	AllowOriginPatterns []string
+	// AllowOriginFunc 在以上规则都不匹配时再做判断
+	AllowOriginFunc func(origin string) bool
+	// AllowMethods 默认 GET、HEAD、POST、PUT、PATCH、DELETE
+	AllowMethods []string
+	// AllowHeaders 是预检允许的请求头，默认 Origin、Content-Type、Accept、Authorization、X-Requested-With；
+	// "*" 表示回显浏览器请求的全部头
+	AllowHeaders []string
+	// ExposeHeaders 是允许前端脚本读取的响应头
+	ExposeHeaders []string
+	// AllowCredentials 允许携带 Cookie 与 Authorization，此时 Allow-Origin 回显具体来源而不是 *；
+	// 不能与 AllowOrigins 为 "*" 同时使用
+	AllowCredentials bool
+	// MaxAge 是浏览器缓存预检结果的时长，按秒取整，0 表示不发送
+	MaxAge time.Duration
+}
+
+// DefaultCORSHeaders 是 AllowHeaders 的默认值。
+var DefaultCORSHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"}
+
+// CORS 按 CORSConfig 处理跨域请求：来源不在白名单时不写任何 CORS 头，由浏览器拦截；
+// 预检请求（OPTIONS 且带 Access-Control-Request-Method）直接以 204 结束，不进入后续 handler。
+// 预检一般不会命中已注册的 OPTIONS 路由，需通过 Engine.Use 注册才能覆盖 404/405 分支。
+// 配置非法（正则无法编译、通配含多个 *、凭证与任意来源同时开启）时 panic。
+func CORS(cfg CORSConfig) tinygee.HandlerFunc {
+	p := NewCORSPolicy(cfg)
+	return func(c *tinygee.Context) {
+		if p.Apply(c.Writer.Header(), c.Req) {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
+
+// CORSHandler 为 net/http 服务提供同样的处理。
+func CORSHandler(cfg CORSConfig, next http.Handler) http.Handler {
+	p := NewCORSPolicy(cfg)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.Apply(w.Header(), r) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CORSPolicy 是预处理后的 CORSConfig，gin 等其他框架可直接调用 Apply 复用同一套规则。
+type CORSPolicy struct {
+	anyOrigin   bool
+	exact       map[string]struct{}
+	wildcards   [][2]string // 前缀与后缀
+	patterns    []*regexp.Regexp
+	originFunc  func(string) bool
+	methods     []string
+	methodsStr  string
+	anyHeader   bool
+	headers     map[string]struct{}
+	headersStr  string
+	exposeStr   string
+	credentials bool
+	maxAge      string
+}
+
+// NewCORSPolicy 校验并编译配置，配置非法时 panic（同 CORS）。
+func NewCORSPolicy(cfg CORSConfig) *CORSPolicy {
+	p := &CORSPolicy{
+		exact:       make(map[string]struct{}),
+		headers:     make(map[string]struct{}),
+		originFunc:  cfg.AllowOriginFunc,
+		credentials: cfg.AllowCredentials,
+	}
+	if len(cfg.AllowOrigins) == 0 && len(cfg.AllowOriginPatterns) == 0 && cfg.AllowOriginFunc == nil {
+		cfg.AllowOrigins = []string{"*"}
+	}
+	for _, o := range cfg.AllowOrigins {
+		o = strings.ToLower(strings.TrimSpace(o))
+		switch n := strings.Count(o, "*"); {
+		case o == "*":
+			p.anyOrigin = true
+		case n == 0:
+			p.exact[o] = struct{}{}
+		case n == 1:
+			prefix, suffix, _ := strings.Cut(o, "*")
+			p.wildcards = append(p.wildcards, [2]string{prefix, suffix})
+		default:
+			panic("tinygee: CORS origin " + strconv.Quote(o) + " has more than one *")
+		}
+	}
+	for _, expr := range cfg.AllowOriginPatterns {
+		p.patterns = append(p.patterns, regexp.MustCompile(expr))
+	}
+	if p.anyOrigin && p.credentials {
+		panic("tinygee: CORS AllowCredentials cannot be used with AllowOrigins \"*\"")
+	}
+
+	methods := cfg.AllowMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	for _, m := range methods {
+		p.methods = append(p.methods, strings.ToUpper(m))
+	}
+	p.methodsStr = strings.Join(p.methods, ", ")
+
+	headers := cfg.AllowHeaders
+	if len(headers) == 0 {
+		headers = DefaultCORSHeaders
+	}
+	for _, h := range headers {
+		if h == "*" {
+			p.anyHeader = true
+			continue
+		}
+		p.headers[strings.ToLower(h)] = struct{}{}
+	}
+	p.headersStr = strings.Join(headers, ", ")
+	p.exposeStr = strings.Join(cfg.ExposeHeaders, ", ")
+	if secs := int64(cfg.MaxAge / time.Second); secs > 0 {
+		p.maxAge = strconv.FormatInt(secs, 10)
+	}
+	return p
+}
+
+// Apply 为请求写入 CORS 响应头，返回 true 表示这是预检请求，调用方应以 204 结束且不再执行后续处理。
+func (p *CORSPolicy) Apply(h http.Header, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	// 只要响应随 Origin 变化就声明 Vary，避免共享缓存把一个来源的响应发给另一个来源
+	if !p.anyOrigin || preflight {
+		addVary(h, "Origin")
+	}
+	if origin == "" {
+		return false
+	}
+	if preflight {
+		addVary(h, "Access-Control-Request-Method")
+		addVary(h, "Access-Control-Request-Headers")
+	}
+	if !p.allowOrigin(origin) {
+		return preflight
+	}
+
+	if preflight {
+		method := r.Header.Get("Access-Control-Request-Method")
+		if !slices.Contains(p.methods, method) {
+			return true
+		}
+		reqHeaders := r.Header.Values("Access-Control-Request-Headers")
+		if !p.allowHeaders(reqHeaders) {
+			return true
+		}
+		p.setOrigin(h, origin)
+		h.Set("Access-Control-Allow-Methods", p.methodsStr)
+		switch {
+		case p.anyHeader && len(reqHeaders) > 0:
+			h.Set("Access-Control-Allow-Headers", strings.Join(reqHeaders, ", "))
+		case !p.anyHeader:
+			h.Set("Access-Control-Allow-Headers", p.headersStr)
+		}
+		if p.maxAge != "" {
+			h.Set("Access-Control-Max-Age", p.maxAge)
+		}
+		return true
+	}
+
+	p.setOrigin(h, origin)
+	if p.exposeStr != "" {
+		h.Set("Access-Control-Expose-Headers", p.exposeStr)
+	}
+	return false
+}
+
+func (p *CORSPolicy) setOrigin(h http.Header, origin string) {
+	if p.anyOrigin {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if p.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (p *CORSPolicy) allowOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	o := strings.ToLower(origin)
+	if _, ok := p.exact[o]; ok {
+		return true
+	}
+	for _, w := range p.wildcards {
+		if len(o) > len(w[0])+len(w[1]) && strings.HasPrefix(o, w[0]) && strings.HasSuffix(o, w[1]) {
+			return true
+		}
+	}
+	for _, re := range p.patterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return p.originFunc != nil && p.originFunc(origin)
+}
+
+// allowHeaders 检查 Access-Control-Request-Headers 中的每一项（逗号分隔，大小写不敏感）。
+func (p *CORSPolicy) allowHeaders(values []string) bool {
+	if p.anyHeader {
+		return true
+	}
+	for _, v := range values {
+		for name := range strings.SplitSeq(v, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if _, ok := p.headers[name]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// addVary 追加 Vary 值，已存在时不重复。
+func addVary(h http.Header, value string) {
+	for _, v := range h.Values("Vary") {
+		for item := range strings.SplitSeq(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), value) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", value)
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+func newCORSApp(cfg CORSConfig) *tinygee.Engine {
+	app := tinygee.New()
+	app.Use(CORS(cfg))
+	app.GET("/todos", func(c *tinygee.Context) {
+		c.SetHeader("Retry-After", "1")
+		c.String(http.StatusOK, "list")
+	})
+	return app
+}
+
+func corsRequest(app http.Handler, method, origin string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/todos", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Add(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSOriginAllowlist(t *testing.T) {
+	app := newCORSApp(CORSConfig{
+		AllowOrigins:        []string{"https://app.example.com", "https://*.example.org", "http://localhost:*"},
+		AllowOriginPatterns: []string{`^https://pr-\d+\.preview\.example\.net$`},
+		AllowCredentials:    true,
+		ExposeHeaders:       []string{"Retry-After"},
+	})
+
+	cases := []struct {
+		origin string
+		ok     bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"https://admin.example.org", true},
+		{"https://example.org", false},
+		{"https://admin.example.org.evil.com", false},
+		{"http://localhost:8000", true},
+		{"https://pr-42.preview.example.net", true},
+		{"https://pr-x.preview.example.net", false},
+		{"https://evil.com", false},
+	}
+	for _, tc := range cases {
+		w := corsRequest(app, http.MethodGet, tc.origin)
+		if w.Code != http.StatusOK || w.Body.String() != "list" {
+			t.Fatalf("%s: actual request should reach handler, got %d", tc.origin, w.Code)
+		}
+		got := w.Header().Get("Access-Control-Allow-Origin")
+		if tc.ok && got != tc.origin {
+			t.Errorf("%s: Allow-Origin = %q", tc.origin, got)
+		}
+		if !tc.ok && got != "" {
+			t.Errorf("%s: should not be allowed, got %q", tc.origin, got)
+		}
+		if tc.ok && (w.Header().Get("Access-Control-Allow-Credentials") != "true" || w.Header().Get("Access-Control-Expose-Headers") != "Retry-After") {
+			t.Errorf("%s: missing credential/expose headers: %v", tc.origin, w.Header())
+		}
+		if w.Header().Get("Vary") != "Origin" {
+			t.Errorf("%s: Vary = %q", tc.origin, w.Header().Get("Vary"))
+		}
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	app := newCORSApp(CORSConfig{
+		AllowOrigins: []string{"https://app.example.com"},
+		AllowMethods: []string{"GET", "POST", "DELETE"},
+		AllowHeaders: []string{"Content-Type", "Authorization"},
+		MaxAge:       10 * time.Minute,
+	})
+
+	// /todos 只注册了 GET，OPTIONS 走 405 分支，仍由 CORS 以 204 应答
+	w := corsRequest(app, http.MethodOptions, "https://app.example.com",
+		"Access-Control-Request-Method", "DELETE",
+		"Access-Control-Request-Headers", "authorization, content-type")
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Fatalf("preflight got %d %q", w.Code, w.Body.String())
+	}
+	h := w.Header()
+	if h.Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		h.Get("Access-Control-Allow-Methods") != "GET, POST, DELETE" ||
+		h.Get("Access-Control-Allow-Headers") != "Content-Type, Authorization" ||
+		h.Get("Access-Control-Max-Age") != "600" {
+		t.Fatalf("preflight headers: %v", h)
+	}
+	if vary := strings.Join(h.Values("Vary"), ", "); vary != "Origin, Access-Control-Request-Method, Access-Control-Request-Headers" {
+		t.Fatalf("Vary = %q", vary)
+	}
+
+	rejected := []struct {
+		name    string
+		origin  string
+		headers []string
+	}{
+		{"origin", "https://evil.com", []string{"Access-Control-Request-Method", "GET"}},
+		{"method", "https://app.example.com", []string{"Access-Control-Request-Method", "PATCH"}},
+		{"header", "https://app.example.com", []string{"Access-Control-Request-Method", "GET", "Access-Control-Request-Headers", "x-debug"}},
+	}
+	for _, tc := range rejected {
+		w := corsRequest(app, http.MethodOptions, tc.origin, tc.headers...)
+		if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("%s: got %d %v", tc.name, w.Code, w.Header())
+		}
+	}
+
+	// 没有 Access-Control-Request-Method 的 OPTIONS 不是预检，照常路由
+	if w := corsRequest(app, http.MethodOptions, "https://app.example.com"); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("plain OPTIONS got %d", w.Code)
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	app := newCORSApp(CORSConfig{AllowHeaders: []string{"*"}})
+
+	w := corsRequest(app, http.MethodGet, "https://a.example.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Vary") != "" {
+		t.Fatalf("any origin headers: %v", w.Header())
+	}
+	w = corsRequest(app, http.MethodOptions, "https://a.example.com",
+		"Access-Control-Request-Method", "PUT",
+		"Access-Control-Request-Headers", "x-custom")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Headers") != "x-custom" {
+		t.Fatalf("wildcard headers preflight: %d %v", w.Code, w.Header())
+	}
+}
+
+func TestCORSHandlerAndInvalidConfig(t *testing.T) {
+	h := CORSHandler(CORSConfig{AllowOrigins: []string{"https://app.example.com"}}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("preflight reached handler")
+	}))
+	w := corsRequest(h, http.MethodOptions, "https://app.example.com", "Access-Control-Request-Method", "POST")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Fatalf("net/http preflight: %d %v", w.Code, w.Header())
+	}
+
+	for name, cfg := range map[string]CORSConfig{
+		"credentials with *": {AllowOrigins: []string{"*"}, AllowCredentials: true},
+		"two wildcards":      {AllowOrigins: []string{"https://*.*.example.com"}},
+		"bad regexp":         {AllowOriginPatterns: []string{"("}},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", name)
+				}
+			}()
+			CORS(cfg)
+		}()
+	}
+}
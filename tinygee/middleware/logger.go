@@ -7,11 +7,11 @@ import (
 	"github.com/xrjjing/Learn4Go/tinygee"
 )
 
-// Logger 记录请求方法、路径、耗时与状态码。
+// Logger 记录请求方法、路径、耗时、状态码与响应字节数。
 func Logger() tinygee.HandlerFunc {
 	return func(c *tinygee.Context) {
 		start := time.Now()
 		c.Next()
-		log.Printf("%s %s -> %d (%v)", c.Method, c.Path, c.StatusCode, time.Since(start))
+		log.Printf("%s %s -> %d %dB (%v)", c.Method, c.Path, c.Writer.Status(), max(c.Writer.Size(), 0), time.Since(start))
 	}
 }
@@ -0,0 +1,86 @@
+// Package metrics 以 Prometheus 文本格式导出 tinygee 的请求指标，不依赖第三方库。
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+// Config 配置请求指标中间件。
+type Config struct {
+	// Registry 为 nil 时使用 Default
+	Registry *Registry
+	// Namespace 非空时作为指标名前缀，如 "todo" 得到 todo_http_requests_total
+	Namespace string
+	// Buckets 是延迟直方图的分桶（秒），默认 DefBuckets；同一 Registry 与 Namespace 下多次调用 New 时必须一致
+	Buckets []float64
+	// SkipPaths 中的请求路径不统计，通常包括 /metrics 自身
+	SkipPaths []string
+}
+
+// unmatchedRoute 是未命中任何路由时的 route 标签，避免原始路径造成高基数。
+const unmatchedRoute = "unmatched"
+
+// New 返回统计请求数、在途请求数与延迟的中间件，标签为 method、route（路由模式）
+// 与 status（状态码类别，如 2xx）。应通过 Engine.Use 全局注册，未命中路由的请求也会被统计。
+func New(cfgs ...Config) tinygee.HandlerFunc {
+	cfg := Config{}
+	if len(cfgs) > 0 {
+		cfg = cfgs[0]
+	}
+	reg := cfg.Registry
+	if reg == nil {
+		reg = Default
+	}
+	prefix := ""
+	if cfg.Namespace != "" {
+		prefix = cfg.Namespace + "_"
+	}
+	requests := reg.Counter(prefix+"http_requests_total", "Total number of HTTP requests.", "method", "route", "status")
+	inFlight := reg.Gauge(prefix+"http_requests_in_flight", "Number of HTTP requests currently being served.", "method", "route")
+	duration := reg.Histogram(prefix+"http_request_duration_seconds", "HTTP request latency in seconds.", cfg.Buckets, "method", "route", "status")
+	skip := make(map[string]struct{}, len(cfg.SkipPaths))
+	for _, p := range cfg.SkipPaths {
+		skip[p] = struct{}{}
+	}
+
+	return func(c *tinygee.Context) {
+		if _, ok := skip[c.Path]; ok {
+			c.Next()
+			return
+		}
+		method := normalizeMethod(c.Method)
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		start := time.Now()
+		inFlight.Inc(method, route)
+		defer inFlight.Dec(method, route)
+		c.Next()
+
+		status := statusClass(c.Writer.Status())
+		requests.Inc(method, route, status)
+		duration.Observe(time.Since(start).Seconds(), method, route, status)
+	}
+}
+
+// normalizeMethod 把非标准方法归为 OTHER，防止客户端随意构造方法名撑大标签集。
+func normalizeMethod(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return m
+	}
+	return "OTHER"
+}
+
+func statusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+func scrape(t *testing.T, h http.Handler) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != ContentType {
+		t.Fatalf("scrape: %d %v", w.Code, w.Header())
+	}
+	return w.Body.String()
+}
+
+func TestMiddlewareLabelsByRoute(t *testing.T) {
+	reg := NewRegistry()
+	app := tinygee.New()
+	app.Use(New(Config{Registry: reg, Buckets: []float64{0.1, 1}, SkipPaths: []string{"/metrics"}}))
+	app.GET("/metrics", reg.Handler())
+	app.GET("/users/:id", func(c *tinygee.Context) { c.String(http.StatusOK, "ok") })
+	app.POST("/users", func(c *tinygee.Context) { c.Status(http.StatusBadRequest) })
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/users/1", nil),
+		httptest.NewRequest(http.MethodGet, "/users/2", nil),
+		httptest.NewRequest(http.MethodPost, "/users", nil),
+		httptest.NewRequest(http.MethodGet, "/nope/123", nil),
+		httptest.NewRequest("PURGE", "/users/1", nil),
+	} {
+		app.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	body := scrape(t, app)
+	for _, want := range []string{
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="GET",route="/users/:id",status="2xx"} 2`,
+		`http_requests_total{method="POST",route="/users",status="4xx"} 1`,
+		`http_requests_total{method="GET",route="unmatched",status="4xx"} 1`,
+		`http_requests_total{method="OTHER",route="unmatched",status="4xx"} 1`,
+		`http_requests_in_flight{method="GET",route="/users/:id"} 0`,
+		"# TYPE http_request_duration_seconds histogram",
+		`http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="2xx",le="0.1"} 2`,
+		`http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="2xx",le="+Inf"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/users/:id",status="2xx"} 2`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Fatalf("missing %q in:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "/users/1") || strings.Contains(body, `route="/metrics"`) {
+		t.Fatalf("raw paths or skipped paths leaked into labels:\n%s", body)
+	}
+}
+
+func TestInFlightGauge(t *testing.T) {
+	reg := NewRegistry()
+	app := tinygee.New()
+	app.Use(New(Config{Registry: reg, Namespace: "todo"}))
+	var during float64
+	app.GET("/slow", func(c *tinygee.Context) {
+		during = reg.Gauge("todo_http_requests_in_flight", "", "method", "route").Value("GET", "/slow")
+	})
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if during != 1 {
+		t.Fatalf("in-flight during request = %v", during)
+	}
+	if got := reg.Counter("todo_http_requests_total", "", "method", "route", "status").Value("GET", "/slow", "2xx"); got != 1 {
+		t.Fatalf("requests total = %v", got)
+	}
+}
+
+func TestRegistryCustomMetrics(t *testing.T) {
+	reg := NewRegistry()
+	jobs := reg.Counter("jobs_processed_total", "Jobs processed.\nBy queue.", "queue")
+	jobs.Inc(`mail"q`)
+	jobs.Add(2.5, `mail"q`)
+	queue := reg.Gauge("queue_depth", "Current depth.")
+	queue.Set(3)
+	queue.Dec()
+	reg.Register(GoCollector())
+
+	if reg.Counter("jobs_processed_total", "", "queue") != jobs {
+		t.Fatal("re-registering the same counter should return the existing one")
+	}
+	body := scrape(t, reg)
+	for _, want := range []string{
+		`# HELP jobs_processed_total Jobs processed.\nBy queue.`,
+		`jobs_processed_total{queue="mail\"q"} 3.5`,
+		"queue_depth 2",
+		"# TYPE go_goroutines gauge",
+		`go_info{version="go`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("missing %q in:\n%s", want, body)
+		}
+	}
+
+	// 相同分桶的重复注册返回同一个直方图
+	if a, b := reg.Histogram("req_seconds", "", nil), reg.Histogram("req_seconds", "", DefBuckets); a != b {
+		t.Fatal("same histogram registered twice should be reused")
+	}
+
+	for name, fn := range map[string]func(){
+		"type conflict":  func() { reg.Gauge("jobs_processed_total", "", "queue") },
+		"label conflict": func() { reg.Counter("jobs_processed_total", "", "other") },
+		"bad name":       func() { reg.Counter("bad-name", "") },
+		"label count":    func() { jobs.Inc() },
+		"negative":       func() { jobs.Add(-1, "q") },
+		"le label":       func() { reg.Histogram("h", "", nil, "le") },
+		"bucket conflict": func() {
+			reg.Histogram("job_seconds", "", []float64{1, 5}, "queue")
+			reg.Histogram("job_seconds", "", []float64{1, 10}, "queue")
+		},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%s: want panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
@@ -0,0 +1,378 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+// ContentType 是 Prometheus 文本格式 0.0.4 的 Content-Type。
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRE  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Collector 在每次抓取时以文本格式写出一组指标，包括 HELP 与 TYPE 行。
+type Collector interface {
+	Collect(w io.Writer) error
+}
+
+// Registry 保存一组指标，抓取时按注册顺序输出。
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+	named      map[string]Collector
+}
+
+// NewRegistry 创建空的注册表，需要运行时指标时再 Register(GoCollector())。
+func NewRegistry() *Registry {
+	return &Registry{named: make(map[string]Collector)}
+}
+
+// Default 是中间件默认使用的注册表，已包含 Go 运行时指标。
+var Default = func() *Registry {
+	r := NewRegistry()
+	r.Register(GoCollector())
+	return r
+}()
+
+// Register 添加自定义 Collector。
+func (r *Registry) Register(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Counter 注册单调递增的计数器；同名同标签的重复注册返回已有的计数器，否则 panic。
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return register(r, name, func() *Counter {
+		return &Counter{vec: newVec(name, help, "counter", labels)}
+	})
+}
+
+// Gauge 注册可增可减的仪表盘。
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	return register(r, name, func() *Gauge {
+		return &Gauge{vec: newVec(name, help, "gauge", labels)}
+	})
+}
+
+// Histogram 注册直方图，buckets 为空时使用 DefBuckets；同名重复注册时分桶也必须相同，否则 panic。
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("metrics: buckets of %q must be sorted", name))
+	}
+	for _, l := range labels {
+		if l == "le" {
+			panic(fmt.Sprintf("metrics: histogram %q must not use label \"le\"", name))
+		}
+	}
+	return register(r, name, func() *Histogram {
+		return &Histogram{vec: newVec(name, help, "histogram", labels), buckets: buckets}
+	})
+}
+
+// DefBuckets 是以秒为单位的默认延迟分桶。
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type metric interface {
+	Collector
+	desc() *vec
+}
+
+func register[M metric](r *Registry, name string, create func() M) M {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := create()
+	if old, ok := r.named[name]; ok {
+		if existing, ok := old.(M); ok && sameLabels(existing.desc().labels, m.desc().labels) && sameBuckets(existing, m) {
+			return existing
+		}
+		panic(fmt.Sprintf("metrics: %q already registered with a different type, labels or buckets", name))
+	}
+	r.named[name] = m
+	r.collectors = append(r.collectors, m)
+	return m
+}
+
+func sameLabels(a, b []string) bool {
+	return strings.Join(a, ",") == strings.Join(b, ",")
+}
+
+// sameBuckets 只比较直方图的分桶，其他类型总是返回 true。
+func sameBuckets(a, b metric) bool {
+	ha, ok := a.(*Histogram)
+	if !ok {
+		return true
+	}
+	return slices.Equal(ha.buckets, b.(*Histogram).buckets)
+}
+
+// Write 以文本格式写出全部指标。
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.Unlock()
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		if err := c.Collect(bw); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP 让 Registry 可以直接作为 http.Handler 挂载。
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	_ = r.Write(w)
+}
+
+// Handler 返回输出注册表的 HandlerFunc，通常挂在 GET /metrics。
+func (r *Registry) Handler() tinygee.HandlerFunc {
+	return func(c *tinygee.Context) { r.ServeHTTP(c.Writer, c.Req) }
+}
+
+// vec 按标签值保存序列，key 为以 0xff 拼接的标签值。
+type vec struct {
+	name, help, typ string
+	labels          []string
+
+	mu     sync.RWMutex
+	series map[string]*series
+}
+
+type series struct {
+	values []string
+	// 计数器与仪表盘只用 val；直方图另外使用 counts 与 count
+	val    atomicFloat
+	counts []atomic.Uint64
+	count  atomic.Uint64
+}
+
+func newVec(name, help, typ string, labels []string) *vec {
+	if !metricNameRE.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+	for _, l := range labels {
+		if !labelNameRE.MatchString(l) || strings.HasPrefix(l, "__") {
+			panic(fmt.Sprintf("metrics: invalid label name %q in %q", l, name))
+		}
+	}
+	return &vec{name: name, help: help, typ: typ, labels: labels, series: make(map[string]*series)}
+}
+
+func (v *vec) desc() *vec { return v }
+
+// get 返回标签值对应的序列，不存在时创建；nBuckets 仅直方图使用。
+func (v *vec) get(values []string, nBuckets int) *series {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %q expects %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	v.mu.RLock()
+	s := v.series[key]
+	v.mu.RUnlock()
+	if s != nil {
+		return s
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s = v.series[key]; s == nil {
+		s = &series{values: append([]string(nil), values...), counts: make([]atomic.Uint64, nBuckets)}
+		v.series[key] = s
+	}
+	return s
+}
+
+// sorted 按标签值排序返回全部序列，使输出稳定。
+func (v *vec) sorted() []*series {
+	v.mu.RLock()
+	out := make([]*series, 0, len(v.series))
+	for _, s := range v.series {
+		out = append(out, s)
+	}
+	v.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		return strings.Join(out[i].values, "\xff") < strings.Join(out[j].values, "\xff")
+	})
+	return out
+}
+
+func (v *vec) header(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, escapeHelp(v.help), v.name, v.typ)
+	return err
+}
+
+// Counter 是单调递增的计数器。
+type Counter struct{ *vec }
+
+// Inc 加 1。
+func (c *Counter) Inc(labelValues ...string) { c.Add(1, labelValues...) }
+
+// Add 增加 delta，delta 为负时 panic。
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: counter %q cannot decrease", c.name))
+	}
+	c.get(labelValues, 0).val.add(delta)
+}
+
+// Value 返回当前值，主要用于测试。
+func (c *Counter) Value(labelValues ...string) float64 { return c.get(labelValues, 0).val.load() }
+
+func (c *Counter) Collect(w io.Writer) error { return collectValues(w, c.vec) }
+
+// Gauge 是可增可减的仪表盘。
+type Gauge struct{ *vec }
+
+// Set 设置为 v。
+func (g *Gauge) Set(v float64, labelValues ...string) { g.get(labelValues, 0).val.store(v) }
+
+// Add 增加 delta，可为负。
+func (g *Gauge) Add(delta float64, labelValues ...string) { g.get(labelValues, 0).val.add(delta) }
+
+// Inc 加 1。
+func (g *Gauge) Inc(labelValues ...string) { g.Add(1, labelValues...) }
+
+// Dec 减 1。
+func (g *Gauge) Dec(labelValues ...string) { g.Add(-1, labelValues...) }
+
+// Value 返回当前值，主要用于测试。
+func (g *Gauge) Value(labelValues ...string) float64 { return g.get(labelValues, 0).val.load() }
+
+func (g *Gauge) Collect(w io.Writer) error { return collectValues(w, g.vec) }
+
+func collectValues(w io.Writer, v *vec) error {
+	if err := v.header(w); err != nil {
+		return err
+	}
+	for _, s := range v.sorted() {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", v.name, formatLabels(v.labels, s.values, "", ""), formatFloat(s.val.load())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Histogram 按上界分桶统计观测值。
+type Histogram struct {
+	*vec
+	buckets []float64
+}
+
+// Observe 记录一次观测值。
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	s := h.get(labelValues, len(h.buckets))
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i].Add(1)
+	}
+	s.val.add(v)
+	s.count.Add(1)
+}
+
+// Count 返回观测次数，主要用于测试。
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	return h.get(labelValues, len(h.buckets)).count.Load()
+}
+
+// Collect 输出累计分桶、_sum 与 _count，桶按上界 le 标注，最后是 +Inf。
+func (h *Histogram) Collect(w io.Writer) error {
+	if err := h.header(w); err != nil {
+		return err
+	}
+	for _, s := range h.sorted() {
+		var cum uint64
+		for i, ub := range h.buckets {
+			cum += s.counts[i].Load()
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, s.values, "le", formatFloat(ub)), cum); err != nil {
+				return err
+			}
+		}
+		count := s.count.Load()
+		labels := formatLabels(h.labels, s.values, "", "")
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.name, formatLabels(h.labels, s.values, "le", "+Inf"), count,
+			h.name, labels, formatFloat(s.val.load()),
+			h.name, labels, count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// atomicFloat 以 CAS 方式在 uint64 位模式上做浮点加法。
+type atomicFloat struct{ bits atomic.Uint64 }
+
+func (f *atomicFloat) load() float64 { return math.Float64frombits(f.bits.Load()) }
+
+func (f *atomicFloat) store(v float64) { f.bits.Store(math.Float64bits(v)) }
+
+func (f *atomicFloat) add(delta float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+// formatLabels 生成 {a="x",b="y"}，extraName 非空时追加一个标签（直方图的 le）。
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, "%s=\"%s\"", n, escapeLabel(values[i]))
+	}
+	if extraName != "" {
+		if len(names) > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, "%s=\"%s\"", extraName, extraValue)
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
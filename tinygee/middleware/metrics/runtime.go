@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+)
+
+// GoCollector 返回输出 Go 运行时指标的 Collector，每次抓取时读取一次 MemStats。
+func GoCollector() Collector {
+	return goCollector{start: time.Now()}
+}
+
+type goCollector struct {
+	start time.Time
+}
+
+func (g goCollector) Collect(w io.Writer) error {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	metrics := []struct {
+		name, help, typ string
+		value           float64
+	}{
+		{"go_goroutines", "Number of goroutines that currently exist.", "gauge", float64(runtime.NumGoroutine())},
+		{"go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", "gauge", float64(ms.Alloc)},
+		{"go_memstats_alloc_bytes_total", "Total number of bytes allocated, even if freed.", "counter", float64(ms.TotalAlloc)},
+		{"go_memstats_sys_bytes", "Number of bytes obtained from system.", "gauge", float64(ms.Sys)},
+		{"go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", "gauge", float64(ms.HeapInuse)},
+		{"go_memstats_heap_objects", "Number of allocated objects.", "gauge", float64(ms.HeapObjects)},
+		{"go_gc_cycles_total", "Number of completed GC cycles.", "counter", float64(ms.NumGC)},
+		{"go_gc_pause_seconds_total", "Total GC stop-the-world pause time.", "counter", float64(ms.PauseTotalNs) / 1e9},
+		{"process_start_time_seconds", "Start time of the process since unix epoch in seconds.", "gauge", float64(g.start.UnixNano()) / 1e9},
+	}
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", m.name, m.help, m.name, m.typ, m.name, formatFloat(m.value)); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "# HELP go_info Information about the Go environment.\n# TYPE go_info gauge\ngo_info{version=\"%s\"} 1\n", escapeLabel(runtime.Version()))
+	return err
+}
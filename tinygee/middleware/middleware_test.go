@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+// SSE 经过 Logger 与 Recover 时不能被缓冲
+func TestSSEThroughLoggerAndRecover(t *testing.T) {
+	release := make(chan struct{})
+	app := tinygee.New()
+	app.Use(Logger(), Recover())
+	app.GET("/events", func(c *tinygee.Context) {
+		c.SSEvent("ping", "1")
+		<-release
+		c.SSEvent("ping", "2")
+	})
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	first, err := reader.ReadString('\n')
+	close(release)
+	if err != nil || first != "event: ping\n" {
+		t.Fatalf("first event not delivered before handler finished: %q %v", first, err)
+	}
+}
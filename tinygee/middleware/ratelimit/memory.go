@@ -11,12 +11,14 @@ import (
 
 // 简易滑动窗口限流（内存版）
 type RateLimiter struct {
-	mu      sync.Mutex
-	clients map[string][]time.Time
-	limit   int
-	window  time.Duration
+	mu        sync.Mutex
+	clients   map[string][]time.Time
+	limit     int
+	window    time.Duration
+	lastSweep time.Time
 }
 
+// New 创建限流器：每个客户端在 window 内最多 limit 次请求，过期记录在后续请求中顺带清理。
 func New(window time.Duration, limit int) *RateLimiter {
 	return &RateLimiter{
 		clients: make(map[string][]time.Time),
@@ -29,7 +31,7 @@ func (r *RateLimiter) Middleware() tinygee.HandlerFunc {
 	return func(c *tinygee.Context) {
 		key := clientIP(c.Req)
 		if !r.allow(key) {
-			c.JSON(http.StatusTooManyRequests, map[string]string{"error": "too many requests"})
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, map[string]string{"error": "too many requests"})
 			return
 		}
 		c.Next()
@@ -40,8 +42,17 @@ func (r *RateLimiter) allow(key string) bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	now := time.Now()
-	requests := r.clients[key]
 	cutoff := now.Add(-r.window)
+	// 每个窗口顺带清理一次已无请求的客户端，避免 map 无限增长，不需要后台协程
+	if now.Sub(r.lastSweep) >= r.window {
+		for k, requests := range r.clients {
+			if len(requests) == 0 || !requests[len(requests)-1].After(cutoff) {
+				delete(r.clients, k)
+			}
+		}
+		r.lastSweep = now
+	}
+	requests := r.clients[key]
 	keep := requests[:0]
 	for _, ts := range requests {
 		if ts.After(cutoff) {
@@ -36,3 +36,25 @@ func TestRateLimit(t *testing.T) {
 		t.Fatalf("after window status %d", w.Code)
 	}
 }
+
+func TestAllowEvictsIdleClients(t *testing.T) {
+	limiter := New(20*time.Millisecond, 1)
+	limiter.allow("1.2.3.4")
+	time.Sleep(30 * time.Millisecond)
+	limiter.allow("5.6.7.8")
+
+	if _, ok := limiter.clients["1.2.3.4"]; ok || len(limiter.clients) != 1 {
+		t.Fatalf("idle client should be evicted, got %v", limiter.clients)
+	}
+
+	// 窗口为 0 时不限流，也不会积累客户端
+	limiter = New(0, 1)
+	for _, ip := range []string{"a", "b", "c"} {
+		if !limiter.allow(ip) || !limiter.allow(ip) {
+			t.Fatalf("zero window should not limit %s", ip)
+		}
+	}
+	if len(limiter.clients) > 1 {
+		t.Fatalf("clients not pruned: %v", limiter.clients)
+	}
+}
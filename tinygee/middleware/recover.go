@@ -23,6 +23,7 @@ func Recover(cfgs ...RecoverConfig) tinygee.HandlerFunc {
 		defer func() {
 			if err := recover(); err != nil {
 				log.Printf("[PANIC] %v\n%s", err, debug.Stack())
+				c.Abort()
 				if cfg.JSON {
 					c.JSON(http.StatusInternalServerError, map[string]any{
 						"error": "internal server error",
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+// RequestIDConfig 配置请求 ID。
+type RequestIDConfig struct {
+	// Header 是读取与回写请求 ID 的头，默认 X-Request-ID
+	Header string
+	// Generator 生成新的请求 ID，默认 16 字节随机数的十六进制
+	Generator func() string
+}
+
+// RequestID 沿用上游传入的合法请求 ID，否则生成新的；
+// ID 通过 Context.RequestID 读取，并写回响应头便于客户端与日志对照。
+func RequestID(cfgs ...RequestIDConfig) tinygee.HandlerFunc {
+	cfg := RequestIDConfig{}
+	if len(cfgs) > 0 {
+		cfg = cfgs[0]
+	}
+	if cfg.Header == "" {
+		cfg.Header = "X-Request-ID"
+	}
+	if cfg.Generator == nil {
+		cfg.Generator = newRequestID
+	}
+	return func(c *tinygee.Context) {
+		id := c.Req.Header.Get(cfg.Header)
+		if !validRequestID(id) {
+			id = cfg.Generator()
+		}
+		c.Set(tinygee.RequestIDKey, id)
+		c.SetHeader(cfg.Header, id)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// validRequestID 只接受不超过 128 字节的字母、数字与 -_.:，避免日志注入。
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		b := id[i]
+		switch {
+		case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9':
+		case b == '-', b == '_', b == '.', b == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
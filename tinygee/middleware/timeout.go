@@ -0,0 +1,289 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+// timeoutKey 在 Context.Keys 中保存外层 Timeout 的状态，供分组/路由上的 Timeout 覆盖。
+const timeoutKey = "tinygee.timeout"
+
+// TimeoutConfig 配置超时响应。
+type TimeoutConfig struct {
+	// StatusCode 默认 503，作为网关转发上游时可改为 504
+	StatusCode int
+	// Body 默认 {"error":"request timeout"}
+	Body []byte
+	// ContentType 默认随 Body：默认 Body 为 JSON，自定义 Body 为 text/plain
+	ContentType string
+}
+
+// Timeout 为请求派生带截止时间的 context（c.Req.Context()），在独立协程中执行后续 handler，
+// 其响应先写入缓冲，按时完成才发给客户端；超时则立即返回 TimeoutConfig 描述的响应，
+// handler 之后的写入返回 http.ErrHandlerTimeout 并被丢弃。
+//
+// 分组或路由上再挂 Timeout 时以最内层为准，可以为慢接口放宽或为个别接口收紧预算，
+// d<=0 表示取消外层限制。超时后仍会等待 handler 返回才释放 Context，
+// 因此 handler 应关注 ctx.Done()；SSE、WebSocket 等需要 Flush/Hijack 的路由不要挂 Timeout。
+func Timeout(d time.Duration, cfgs ...TimeoutConfig) tinygee.HandlerFunc {
+	cfg := TimeoutConfig{}
+	if len(cfgs) > 0 {
+		cfg = cfgs[0]
+	}
+	if cfg.StatusCode == 0 {
+		cfg.StatusCode = http.StatusServiceUnavailable
+	}
+	if cfg.Body == nil {
+		cfg.Body = []byte(`{"error":"request timeout"}`)
+		if cfg.ContentType == "" {
+			cfg.ContentType = tinygee.MIMEJSON + "; charset=utf-8"
+		}
+	}
+	if cfg.ContentType == "" {
+		cfg.ContentType = "text/plain; charset=utf-8"
+	}
+
+	return func(c *tinygee.Context) {
+		if v, ok := c.Get(timeoutKey); ok {
+			c.Req = c.Req.WithContext(v.(*timeoutState).override(c.Req.Context(), d, &cfg))
+			c.Next()
+			return
+		}
+		if d <= 0 {
+			c.Next()
+			return
+		}
+
+		req, w := c.Req, c.Writer
+		ctx, cancel := context.WithTimeout(req.Context(), d)
+		st := &timeoutState{parent: req.Context(), ctx: ctx, cfg: &cfg, changed: make(chan struct{}, 1)}
+		st.cancels = append(st.cancels, cancel)
+		defer st.cancelAll()
+
+		tw := &timeoutWriter{w: w, h: w.Header().Clone(), status: http.StatusOK, size: -1}
+		c.Set(timeoutKey, st)
+		c.Req = req.WithContext(ctx)
+		c.Writer = tw
+
+		done := make(chan any, 1)
+		go func() {
+			defer func() { done <- recover() }()
+			c.Next()
+		}()
+
+		p, res := st.wait(done)
+		if res != nil {
+			tw.timeout(res.StatusCode, len(res.Body))
+			h := w.Header()
+			h.Set("Content-Type", res.ContentType)
+			h.Set("Content-Length", strconv.Itoa(len(res.Body)))
+			w.WriteHeader(res.StatusCode)
+			_, _ = w.Write(res.Body)
+			w.Flush()
+			// Context 来自对象池，必须等 handler 返回；此后 c.Writer 保持为已超时的 tw，
+			// 迟到的写入与错误渲染都被丢弃，外层中间件读到的是超时响应的状态码
+			p = <-done
+			c.Abort()
+		} else {
+			c.Writer = w
+			if p == nil {
+				tw.commit()
+			}
+		}
+		c.Req = req
+		if p != nil {
+			panic(p)
+		}
+	}
+}
+
+// timeoutState 记录当前生效的截止时间，内层 Timeout 通过 override 替换。
+type timeoutState struct {
+	parent  context.Context // 原始请求 context，客户端断开时取消
+	changed chan struct{}
+
+	mu       sync.Mutex
+	ctx      context.Context
+	cfg      *TimeoutConfig
+	cancels  []context.CancelFunc
+	finished bool
+}
+
+// override 以 base 的值为基础、不继承其截止时间，派生新的 context 并设为当前生效的截止时间；
+// 客户端断开仍会取消新 context。外层已超时或已结束时原样返回 base。
+func (st *timeoutState) override(base context.Context, d time.Duration, cfg *TimeoutConfig) context.Context {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.finished {
+		return base
+	}
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if d > 0 {
+		ctx, cancel = context.WithTimeout(context.WithoutCancel(base), d)
+	} else {
+		ctx, cancel = context.WithCancel(context.WithoutCancel(base))
+	}
+	stop := context.AfterFunc(st.parent, cancel)
+	st.cancels = append(st.cancels, cancel, func() { stop() })
+	st.ctx, st.cfg = ctx, cfg
+	select {
+	case st.changed <- struct{}{}:
+	default:
+	}
+	return ctx
+}
+
+// wait 等待 handler 结束或当前截止时间到达；超时返回生效的配置，客户端断开则继续等待 handler。
+func (st *timeoutState) wait(done <-chan any) (any, *TimeoutConfig) {
+	for {
+		st.mu.Lock()
+		ctx := st.ctx
+		st.mu.Unlock()
+		select {
+		case p := <-done:
+			st.finish()
+			return p, nil
+		case <-st.changed:
+		case <-ctx.Done():
+			st.mu.Lock()
+			if ctx != st.ctx {
+				st.mu.Unlock()
+				continue
+			}
+			st.finished = true
+			cfg := st.cfg
+			st.mu.Unlock()
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return <-done, nil
+			}
+			return nil, cfg
+		}
+	}
+}
+
+func (st *timeoutState) finish() {
+	st.mu.Lock()
+	st.finished = true
+	st.mu.Unlock()
+}
+
+func (st *timeoutState) cancelAll() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, cancel := range st.cancels {
+		cancel()
+	}
+}
+
+// timeoutWriter 缓冲 handler 的响应头与响应体；只有外层协程会接触真实的 w。
+type timeoutWriter struct {
+	w tinygee.ResponseWriter
+	h http.Header
+
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	status   int
+	size     int
+	timedOut bool
+}
+
+var _ tinygee.ResponseWriter = (*timeoutWriter)(nil)
+
+func (tw *timeoutWriter) Header() http.Header { return tw.h }
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if code > 0 && !tw.timedOut && tw.size < 0 {
+		tw.status = code
+	}
+}
+
+func (tw *timeoutWriter) WriteHeaderNow() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.timedOut && tw.size < 0 {
+		tw.size = 0
+	}
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.size < 0 {
+		tw.size = 0
+	}
+	n, err := tw.buf.Write(b)
+	tw.size += n
+	return n, err
+}
+
+func (tw *timeoutWriter) WriteString(s string) (int, error) {
+	return tw.Write([]byte(s))
+}
+
+func (tw *timeoutWriter) Status() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.status
+}
+
+func (tw *timeoutWriter) Size() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.size
+}
+
+func (tw *timeoutWriter) Written() bool { return tw.Size() >= 0 }
+
+// Flush 只标记响应头已发送，数据仍在 handler 结束后一次性写出。
+func (tw *timeoutWriter) Flush() { tw.WriteHeaderNow() }
+
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("tinygee: hijack is not supported under Timeout")
+}
+
+func (tw *timeoutWriter) Push(string, *http.PushOptions) error { return http.ErrNotSupported }
+
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter { return tw.w }
+
+// timeout 使之后的写入失效，并让 Status/Size 报告已发送的超时响应。
+func (tw *timeoutWriter) timeout(status, size int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.timedOut, tw.status, tw.size = true, status, size
+}
+
+// commit 在 handler 按时结束后把缓冲的响应交给真实的 ResponseWriter；
+// 只设置了状态码而没有写响应体时保持未发送，留给 ErrorHandler 处理。
+func (tw *timeoutWriter) commit() {
+	dst := tw.w.Header()
+	clear(dst)
+	for k, v := range tw.h {
+		dst[k] = v
+	}
+	tw.w.WriteHeader(tw.status)
+	if tw.size < 0 {
+		return
+	}
+	if tw.buf.Len() == 0 {
+		tw.w.WriteHeaderNow()
+		return
+	}
+	_, _ = tw.w.Write(tw.buf.Bytes())
+}
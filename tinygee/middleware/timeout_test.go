@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+// sleepOrDone 模拟遵守 ctx 的慢处理，返回 ctx 的错误
+func sleepOrDone(c *tinygee.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-c.Req.Context().Done():
+		return c.Req.Context().Err()
+	}
+}
+
+func serve(app http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestTimeoutPassThrough(t *testing.T) {
+	app := tinygee.New()
+	app.Use(func(c *tinygee.Context) {
+		c.SetHeader("X-Outer", "1")
+		c.Next()
+	}, Timeout(time.Second))
+	app.GET("/ok", func(c *tinygee.Context) {
+		if _, ok := c.Req.Context().Deadline(); !ok {
+			t.Error("handler context has no deadline")
+		}
+		c.SetHeader("X-Inner", "1")
+		c.String(http.StatusCreated, "done")
+	})
+	app.GET("/fail", func(c *tinygee.Context) {
+		_ = c.AbortWithError(http.StatusBadRequest, errors.New("bad input")).SetType(tinygee.ErrorTypePublic)
+	})
+
+	w := serve(app, "/ok")
+	if w.Code != http.StatusCreated || w.Body.String() != "done" {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("X-Outer") != "1" || w.Header().Get("X-Inner") != "1" {
+		t.Fatalf("headers lost: %v", w.Header())
+	}
+
+	// 只记录了错误时仍由 ErrorHandler 渲染
+	w = serve(app, "/fail")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 from ErrorHandler, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestTimeoutDiscardsLateWrites(t *testing.T) {
+	var status int
+	handlerErr := make(chan error, 1)
+	app := tinygee.New()
+	app.Use(func(c *tinygee.Context) {
+		c.Next()
+		status = c.Writer.Status()
+	}, Timeout(20*time.Millisecond))
+	app.GET("/slow", func(c *tinygee.Context) {
+		c.SetHeader("X-Late", "1")
+		handlerErr <- sleepOrDone(c, time.Second)
+		c.String(http.StatusOK, "too late")
+	})
+
+	w := serve(app, "/slow")
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != `{"error":"request timeout"}` {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("X-Late") != "" {
+		t.Fatal("headers from timed out handler leaked")
+	}
+	if err := <-handlerErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("handler ctx err = %v", err)
+	}
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("outer middleware saw status %d", status)
+	}
+}
+
+func TestTimeoutCustomResponse(t *testing.T) {
+	app := tinygee.New()
+	app.Use(Timeout(10*time.Millisecond, TimeoutConfig{StatusCode: http.StatusGatewayTimeout, Body: []byte("upstream timeout")}))
+	app.GET("/slow", func(c *tinygee.Context) { _ = sleepOrDone(c, time.Second) })
+
+	w := serve(app, "/slow")
+	if w.Code != http.StatusGatewayTimeout || w.Body.String() != "upstream timeout" {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Fatalf("content type %q", ct)
+	}
+}
+
+func TestTimeoutOverride(t *testing.T) {
+	app := tinygee.New()
+	app.Use(Timeout(20 * time.Millisecond))
+	app.GET("/crud", func(c *tinygee.Context) {
+		_ = sleepOrDone(c, 60*time.Millisecond)
+		c.String(http.StatusOK, "crud")
+	})
+	reports := app.Group("/reports")
+	reports.Use(Timeout(time.Second))
+	reports.GET("/monthly", func(c *tinygee.Context) {
+		if err := sleepOrDone(c, 60*time.Millisecond); err != nil {
+			t.Errorf("report ctx err = %v", err)
+		}
+		c.String(http.StatusOK, "report")
+	})
+	reports.GET("/quick", Timeout(5*time.Millisecond, TimeoutConfig{StatusCode: http.StatusGatewayTimeout}), func(c *tinygee.Context) {
+		_ = sleepOrDone(c, time.Second)
+	})
+	app.GET("/export", Timeout(0), func(c *tinygee.Context) {
+		if _, ok := c.Req.Context().Deadline(); ok {
+			t.Error("Timeout(0) should remove the deadline")
+		}
+		_ = sleepOrDone(c, 60*time.Millisecond)
+		c.String(http.StatusOK, "export")
+	})
+
+	cases := []struct {
+		path string
+		code int
+	}{
+		{"/crud", http.StatusServiceUnavailable},
+		{"/reports/monthly", http.StatusOK},
+		{"/reports/quick", http.StatusGatewayTimeout},
+		{"/export", http.StatusOK},
+	}
+	for _, tc := range cases {
+		if w := serve(app, tc.path); w.Code != tc.code {
+			t.Errorf("%s: got %d %q, want %d", tc.path, w.Code, w.Body.String(), tc.code)
+		}
+	}
+}
+
+func TestTimeoutPanicReachesRecover(t *testing.T) {
+	app := tinygee.New()
+	app.Use(Recover(), Timeout(time.Second))
+	app.GET("/panic", func(c *tinygee.Context) {
+		c.String(http.StatusOK, "partial")
+		panic("boom")
+	})
+
+	w := serve(app, "/panic")
+	if w.Code != http.StatusInternalServerError || w.Body.String() == "partial" {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// NewTracerFromEnv 按环境变量创建 Tracer，便于多个服务用同一套配置互相关联：
+//
+//	TRACE_EXPORTER       stdout | file | otlp，为空时只传播 traceparent 不导出
+//	TRACE_FILE           file 导出路径，默认 traces.jsonl（10MB 轮转，保留 3 个）
+//	TRACE_SAMPLE_RATIO   新链路的采样比例，默认 1
+//	OTEL_EXPORTER_OTLP_TRACES_ENDPOINT  otlp 接收地址，默认 http://localhost:4318/v1/traces
+func NewTracerFromEnv(service string) (*Tracer, error) {
+	cfg := TracerConfig{ServiceName: service}
+	if v := os.Getenv("TRACE_SAMPLE_RATIO"); v != "" {
+		ratio, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("tracing: invalid TRACE_SAMPLE_RATIO %q: %w", v, err)
+		}
+		cfg.Sampler = TraceIDRatio(ratio)
+	}
+	switch kind := os.Getenv("TRACE_EXPORTER"); kind {
+	case "":
+	case "stdout":
+		cfg.Exporter = NewStdoutExporter()
+	case "file":
+		path := os.Getenv("TRACE_FILE")
+		if path == "" {
+			path = "traces.jsonl"
+		}
+		exp, err := NewFileExporter(path, 10<<20, 3)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Exporter = exp
+	case "otlp":
+		endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+		if endpoint == "" {
+			endpoint = "http://localhost:4318/v1/traces"
+		}
+		cfg.Exporter = NewOTLPExporter(OTLPConfig{Endpoint: endpoint})
+	default:
+		return nil, fmt.Errorf("tracing: unknown TRACE_EXPORTER %q", kind)
+	}
+	return NewTracer(cfg), nil
+}
@@ -0,0 +1,141 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Exporter 把结束的 span 发送到外部系统，由 Tracer 的后台协程串行调用。
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// JSONExporter 把每个 span 编码为一行 JSON。
+type JSONExporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONExporter 写入 w。
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	return &JSONExporter{enc: json.NewEncoder(w)}
+}
+
+// NewStdoutExporter 写入标准输出，适合本地调试。
+func NewStdoutExporter() *JSONExporter {
+	return NewJSONExporter(os.Stdout)
+}
+
+func (e *JSONExporter) Export(_ context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := range spans {
+		if err := e.enc.Encode(&spans[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *JSONExporter) Shutdown(context.Context) error { return nil }
+
+// FileExporter 以 JSON 行写入文件，超过 MaxBytes 时轮转：
+// path -> path.1 -> path.2 ...，最多保留 MaxBackups 个旧文件。
+type FileExporter struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// NewFileExporter 以追加方式打开 path；maxBytes<=0 时不轮转。
+func NewFileExporter(path string, maxBytes int64, maxBackups int) (*FileExporter, error) {
+	e := &FileExporter{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := e.open(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *FileExporter) open() error {
+	f, err := os.OpenFile(e.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	e.f, e.size = f, fi.Size()
+	return nil
+}
+
+// rotate 关闭当前文件并依次后移旧文件，超出 maxBackups 的最旧文件被删除。
+func (e *FileExporter) rotate() error {
+	if err := e.f.Close(); err != nil {
+		return err
+	}
+	if e.maxBackups <= 0 {
+		if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return e.open()
+	}
+	_ = os.Remove(fmt.Sprintf("%s.%d", e.path, e.maxBackups))
+	for i := e.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(fmt.Sprintf("%s.%d", e.path, i), fmt.Sprintf("%s.%d", e.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(e.path, e.path+".1"); err != nil {
+		return err
+	}
+	return e.open()
+}
+
+func (e *FileExporter) Export(_ context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.f == nil {
+		return os.ErrClosed
+	}
+	for i := range spans {
+		line, err := json.Marshal(&spans[i])
+		if err != nil {
+			return err
+		}
+		line = append(line, '\n')
+		if e.maxBytes > 0 && e.size > 0 && e.size+int64(len(line)) > e.maxBytes {
+			if err := e.rotate(); err != nil {
+				return err
+			}
+		}
+		n, err := e.f.Write(line)
+		e.size += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Shutdown 关闭文件。
+func (e *FileExporter) Shutdown(context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.f == nil {
+		return nil
+	}
+	err := e.f.Close()
+	e.f = nil
+	return err
+}
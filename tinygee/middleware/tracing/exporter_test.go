@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleSpans(n int) []SpanData {
+	spans := make([]SpanData, n)
+	now := time.Unix(1700000000, 0)
+	for i := range spans {
+		spans[i] = SpanData{
+			Service: "svc", Name: "op", Kind: KindServer,
+			TraceID: newTraceID(), SpanID: newSpanID(), ParentSpanID: newSpanID(),
+			Start: now, End: now.Add(time.Millisecond),
+			Attrs:  []Attr{{Key: "http.response.status_code", Value: 200}, {Key: "ok", Value: true}},
+			Events: []Event{{Name: "exception", Time: now, Attrs: []Attr{{Key: "exception.message", Value: "boom"}}}},
+			Status: StatusError, StatusMessage: "boom",
+		}
+	}
+	return spans
+}
+
+func TestFileExporterRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+	line, _ := json.Marshal(sampleSpans(1)[0])
+	// 每个文件最多容纳两行
+	exp, err := NewFileExporter(path, int64(len(line)+1)*2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if err := exp.Export(context.Background(), sampleSpans(2)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := exp.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := strings.Count(string(b), "\n"); n != 2 {
+			t.Fatalf("%s has %d lines", name, n)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("only 2 backups should be kept: %v", err)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var got map[string]any
+	var auth string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}))
+	defer collector.Close()
+
+	tr := NewTracer(TracerConfig{
+		ServiceName: "gateway",
+		Exporter: NewOTLPExporter(OTLPConfig{
+			Endpoint: collector.URL + "/v1/traces",
+			Headers:  map[string]string{"Authorization": "Bearer t"},
+		}),
+	})
+	ctx, parent := tr.Start(context.Background(), "GET /api", WithKind(KindServer))
+	_, child := Start(ctx, "db.query")
+	child.SetAttr("rows", 3)
+	child.End()
+	parent.End()
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if auth != "Bearer t" {
+		t.Fatalf("headers not sent: %q", auth)
+	}
+	rs := got["resourceSpans"].([]any)[0].(map[string]any)
+	svc := rs["resource"].(map[string]any)["attributes"].([]any)[0].(map[string]any)
+	if svc["key"] != "service.name" || svc["value"].(map[string]any)["stringValue"] != "gateway" {
+		t.Fatalf("resource %v", rs["resource"])
+	}
+	spans := rs["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)
+	if len(spans) != 2 {
+		t.Fatalf("want 2 spans, got %v", spans)
+	}
+	first := spans[0].(map[string]any)
+	second := spans[1].(map[string]any)
+	if first["name"] != "db.query" || first["parentSpanId"] != second["spanId"] || first["traceId"] != second["traceId"] {
+		t.Fatalf("spans not linked: %v %v", first, second)
+	}
+	if len(first["traceId"].(string)) != 32 || second["kind"] != float64(KindServer) {
+		t.Fatalf("bad encoding: %v", second)
+	}
+	attr := first["attributes"].([]any)[0].(map[string]any)
+	if attr["value"].(map[string]any)["intValue"] != "3" {
+		t.Fatalf("int attr should be a decimal string: %v", attr)
+	}
+
+	failing := NewOTLPExporter(OTLPConfig{Endpoint: collector.URL + "/wrong"})
+	if err := failing.Export(context.Background(), sampleSpans(1)); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatalf("want 400 error, got %v", err)
+	}
+}
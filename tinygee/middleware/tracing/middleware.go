@@ -0,0 +1,161 @@
+// Package tracing 实现 W3C Trace Context 传播与轻量 span API，
+// 可导出为标准输出 JSON、轮转文件或 OTLP/HTTP JSON。
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+// Config 配置追踪中间件。
+type Config struct {
+	// SkipPaths 中的请求路径不创建 span，但仍会透传上游的 traceparent
+	SkipPaths []string
+}
+
+// New 为每个请求创建 KindServer span，名称为 "方法 路由模式"（未命中时只有方法），
+// 上游带合法 traceparent 时加入其链路。span 存入 c.Req 的 context，
+// handler 中用 SpanFromContext(c.Req.Context()) 或 Start 创建子 span。
+func New(t *Tracer, cfgs ...Config) tinygee.HandlerFunc {
+	cfg := Config{}
+	if len(cfgs) > 0 {
+		cfg = cfgs[0]
+	}
+	skip := make(map[string]struct{}, len(cfg.SkipPaths))
+	for _, p := range cfg.SkipPaths {
+		skip[p] = struct{}{}
+	}
+
+	return func(c *tinygee.Context) {
+		ctx := c.Req.Context()
+		if parent, ok := Extract(c.Req.Header); ok {
+			ctx = ContextWithRemoteSpanContext(ctx, parent)
+		}
+		if _, ok := skip[c.Path]; ok {
+			c.Req = c.Req.WithContext(ctx)
+			c.Next()
+			return
+		}
+
+		name := c.Method
+		if route := c.FullPath(); route != "" {
+			name += " " + route
+		}
+		ctx, span := t.Start(ctx, name, WithKind(KindServer), WithAttrs(
+			Attr{Key: "http.request.method", Value: c.Method},
+			Attr{Key: "url.path", Value: c.Path},
+			Attr{Key: "client.address", Value: c.ClientIP()},
+		))
+		if route := c.FullPath(); route != "" {
+			span.SetAttr("http.route", route)
+		}
+		if ua := c.Req.UserAgent(); ua != "" {
+			span.SetAttr("user_agent.original", ua)
+		}
+		c.Req = c.Req.WithContext(ctx)
+		defer span.End()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttr("http.response.status_code", status)
+		if id := c.RequestID(); id != "" {
+			span.SetAttr("request.id", id)
+		}
+		if last := c.Errors.Last(); last != nil {
+			span.RecordError(last)
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(StatusError, http.StatusText(status))
+		}
+	}
+}
+
+// Handler 为普通 net/http 服务提供同样的 server span；
+// 使用 Go 1.22 ServeMux 时以匹配到的 r.Pattern 命名。
+func Handler(t *Tracer, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if parent, ok := Extract(r.Header); ok {
+			ctx = ContextWithRemoteSpanContext(ctx, parent)
+		}
+		ctx, span := t.Start(ctx, r.Method, WithKind(KindServer), WithAttrs(
+			Attr{Key: "http.request.method", Value: r.Method},
+			Attr{Key: "url.path", Value: r.URL.Path},
+		))
+		defer span.End()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		r = r.WithContext(ctx)
+		h.ServeHTTP(rec, r)
+
+		// ServeMux 会把命中的模式写回传入的 *http.Request
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+			span.SetAttr("http.route", r.Pattern)
+		}
+		span.SetAttr("http.response.status_code", rec.status)
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(StatusError, http.StatusText(rec.status))
+		}
+	})
+}
+
+// Transport 包装 http.RoundTripper，为每个出站请求创建 KindClient span 并注入 traceparent；
+// 请求 context 中没有 span 时只透传远端链路信息。
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		ctx, span := Start(r.Context(), fmt.Sprintf("%s %s", r.Method, r.URL.Host), WithKind(KindClient), WithAttrs(
+			Attr{Key: "http.request.method", Value: r.Method},
+			Attr{Key: "url.full", Value: r.URL.String()},
+		))
+		defer span.End()
+		r = r.Clone(ctx)
+		Inject(ctx, r.Header)
+		resp, err := base.RoundTrip(r)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		span.SetAttr("http.response.status_code", resp.StatusCode)
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.SetStatus(StatusError, resp.Status)
+		}
+		return resp, nil
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// statusRecorder 记录 net/http handler 写出的状态码，并透传 Flush。
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+func (w *statusRecorder) WriteHeader(code int) {
+	if !w.wrote {
+		w.status, w.wrote = code, true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusRecorder) Unwrap() http.ResponseWriter { return w.ResponseWriter }
@@ -0,0 +1,195 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// OTLPConfig 配置 OTLP/HTTP JSON 导出。
+type OTLPConfig struct {
+	// Endpoint 是完整的接收地址，如 http://localhost:4318/v1/traces
+	Endpoint string
+	// Headers 随每次请求发送，常用于鉴权
+	Headers map[string]string
+	// Timeout 是单次导出的超时，默认 10 秒
+	Timeout time.Duration
+	// Client 为 nil 时使用 http.DefaultClient
+	Client *http.Client
+}
+
+// OTLPExporter 按 OTLP/HTTP 的 JSON 编码把 span POST 到 collector。
+type OTLPExporter struct {
+	cfg OTLPConfig
+}
+
+// NewOTLPExporter 创建导出器。
+func NewOTLPExporter(cfg OTLPConfig) *OTLPExporter {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &OTLPExporter{cfg: cfg}
+}
+
+func (e *OTLPExporter) Export(ctx context.Context, spans []SpanData) error {
+	body, err := json.Marshal(otlpRequest(spans))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("tracing: otlp export: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("tracing: otlp export: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func (e *OTLPExporter) Shutdown(context.Context) error { return nil }
+
+// 以下类型对应 OTLP JSON 编码：ID 为十六进制字符串，64 位整数为十进制字符串。
+type (
+	otlpExport struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		TraceState        string         `json:"traceState,omitempty"`
+		Name              string         `json:"name"`
+		Kind              Kind           `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Events            []otlpEvent    `json:"events,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpEvent struct {
+		TimeUnixNano string         `json:"timeUnixNano"`
+		Name         string         `json:"name"`
+		Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	}
+	otlpStatus struct {
+		Code    StatusCode `json:"code,omitempty"`
+		Message string     `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+// otlpRequest 按 service 分组生成一次导出请求。
+func otlpRequest(spans []SpanData) otlpExport {
+	var out otlpExport
+	index := make(map[string]int)
+	for i := range spans {
+		d := &spans[i]
+		n, ok := index[d.Service]
+		if !ok {
+			n = len(out.ResourceSpans)
+			index[d.Service] = n
+			out.ResourceSpans = append(out.ResourceSpans, otlpResourceSpans{
+				Resource:   otlpResource{Attributes: []otlpKeyValue{otlpAttr(Attr{Key: "service.name", Value: d.Service})}},
+				ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "tinygee"}}},
+			})
+		}
+		scope := &out.ResourceSpans[n].ScopeSpans[0]
+		scope.Spans = append(scope.Spans, toOTLPSpan(d))
+	}
+	return out
+}
+
+func toOTLPSpan(d *SpanData) otlpSpan {
+	s := otlpSpan{
+		TraceID:           d.TraceID.String(),
+		SpanID:            d.SpanID.String(),
+		TraceState:        d.TraceState,
+		Name:              d.Name,
+		Kind:              d.Kind,
+		StartTimeUnixNano: strconv.FormatInt(d.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(d.End.UnixNano(), 10),
+		Status:            otlpStatus{Code: d.Status, Message: d.StatusMessage},
+	}
+	if d.ParentSpanID.IsValid() {
+		s.ParentSpanID = d.ParentSpanID.String()
+	}
+	for _, a := range d.Attrs {
+		s.Attributes = append(s.Attributes, otlpAttr(a))
+	}
+	for _, ev := range d.Events {
+		e := otlpEvent{TimeUnixNano: strconv.FormatInt(ev.Time.UnixNano(), 10), Name: ev.Name}
+		for _, a := range ev.Attrs {
+			e.Attributes = append(e.Attributes, otlpAttr(a))
+		}
+		s.Events = append(s.Events, e)
+	}
+	return s
+}
+
+func otlpAttr(a Attr) otlpKeyValue {
+	var v otlpValue
+	switch x := a.Value.(type) {
+	case string:
+		v.StringValue = &x
+	case bool:
+		v.BoolValue = &x
+	case int:
+		s := strconv.FormatInt(int64(x), 10)
+		v.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(x, 10)
+		v.IntValue = &s
+	case uint:
+		s := strconv.FormatUint(uint64(x), 10)
+		v.IntValue = &s
+	case float64:
+		v.DoubleValue = &x
+	default:
+		s := fmt.Sprint(x)
+		v.StringValue = &s
+	}
+	return otlpKeyValue{Key: a.Key, Value: v}
+}
@@ -0,0 +1,422 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Kind 是 span 类型，取值与 OTLP 一致。
+type Kind int
+
+const (
+	KindInternal Kind = iota + 1
+	KindServer
+	KindClient
+	KindProducer
+	KindConsumer
+)
+
+// StatusCode 是 span 状态，取值与 OTLP 一致。
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = iota
+	StatusOK
+	StatusError
+)
+
+// Attr 是一个 span 属性，Value 支持 string、bool、整数与浮点数，其余类型按 %v 转为字符串。
+type Attr struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+// Event 是 span 生命周期内的一个时间点事件。
+type Event struct {
+	Name  string    `json:"name"`
+	Time  time.Time `json:"time"`
+	Attrs []Attr    `json:"attrs,omitempty"`
+}
+
+// SpanData 是结束后的 span 快照，交给 Exporter 导出。
+type SpanData struct {
+	Service       string     `json:"service,omitempty"`
+	Name          string     `json:"name"`
+	Kind          Kind       `json:"kind"`
+	TraceID       TraceID    `json:"trace_id"`
+	SpanID        SpanID     `json:"span_id"`
+	ParentSpanID  SpanID     `json:"parent_span_id,omitzero"`
+	TraceState    string     `json:"trace_state,omitempty"`
+	Start         time.Time  `json:"start"`
+	End           time.Time  `json:"end"`
+	Attrs         []Attr     `json:"attrs,omitempty"`
+	Events        []Event    `json:"events,omitempty"`
+	Status        StatusCode `json:"status"`
+	StatusMessage string     `json:"status_message,omitempty"`
+}
+
+// Span 是进行中的一次操作。所有方法对 nil 接收者安全，
+// 未启用追踪时 handler 可以直接调用而无需判空。
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext 返回用于传播的链路信息。
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetName 修改 span 名称。
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Name = name
+	s.mu.Unlock()
+}
+
+// SetAttr 设置属性，同名属性会被覆盖。
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.data.Attrs {
+		if s.data.Attrs[i].Key == key {
+			s.data.Attrs[i].Value = value
+			return
+		}
+	}
+	s.data.Attrs = append(s.data.Attrs, Attr{Key: key, Value: value})
+}
+
+// AddEvent 记录一个事件。
+func (s *Span) AddEvent(name string, attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Events = append(s.data.Events, Event{Name: name, Time: time.Now(), Attrs: attrs})
+	s.mu.Unlock()
+}
+
+// SetStatus 设置 span 状态。
+func (s *Span) SetStatus(code StatusCode, msg string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Status, s.data.StatusMessage = code, msg
+	s.mu.Unlock()
+}
+
+// RecordError 记录一个 exception 事件并把状态置为 Error，err 为 nil 时忽略。
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.AddEvent("exception", Attr{Key: "exception.message", Value: err.Error()})
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End 结束 span 并交给 Tracer 导出，重复调用无效。
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+	if s.sc.IsSampled() {
+		s.tracer.enqueue(&data)
+	}
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// ContextWithSpan 把 span 存入 context，之后的 Start 以它为父 span。
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// SpanFromContext 取出当前 span，没有时返回 nil（nil 上的方法都是空操作）。
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// ContextWithRemoteSpanContext 把上游传来的链路信息存入 context，
+// 适用于消息队列等不经过 HTTP 的场景。
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// SpanContextFromContext 返回 context 中当前 span 或远端父 span 的链路信息。
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if s := SpanFromContext(ctx); s != nil {
+		return s.sc
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// Inject 把 ctx 中的链路信息写入请求头，调用下游 HTTP 服务前使用。
+func Inject(ctx context.Context, h http.Header) {
+	InjectSpanContext(SpanContextFromContext(ctx), h)
+}
+
+// Start 以 ctx 中的 span 为父创建子 span，沿用父 span 的 Tracer；
+// ctx 中没有 span 时返回 nil span，不产生任何开销。
+func Start(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.tracer.Start(ctx, name, opts...)
+}
+
+// SpanOption 配置新建的 span。
+type SpanOption func(*Span)
+
+// WithKind 设置 span 类型，默认 KindInternal。
+func WithKind(k Kind) SpanOption {
+	return func(s *Span) { s.data.Kind = k }
+}
+
+// WithAttrs 设置初始属性。
+func WithAttrs(attrs ...Attr) SpanOption {
+	return func(s *Span) { s.data.Attrs = append(s.data.Attrs, attrs...) }
+}
+
+// Sampler 决定没有父 span 的新链路是否采样；有父 span 时始终沿用父 span 的采样位。
+type Sampler func(TraceID) bool
+
+// AlwaysSample 采样全部链路。
+func AlwaysSample(TraceID) bool { return true }
+
+// NeverSample 不采样任何链路，仍然传播 traceparent。
+func NeverSample(TraceID) bool { return false }
+
+// TraceIDRatio 按 TraceID 的低 8 字节采样约 ratio 比例的链路，同一链路在各服务的结果一致。
+func TraceIDRatio(ratio float64) Sampler {
+	if ratio >= 1 {
+		return AlwaysSample
+	}
+	if ratio <= 0 {
+		return NeverSample
+	}
+	bound := uint64(ratio * (1 << 63))
+	return func(id TraceID) bool {
+		return binary.BigEndian.Uint64(id[8:])>>1 < bound
+	}
+}
+
+// TracerConfig 配置 Tracer。
+type TracerConfig struct {
+	// ServiceName 作为导出数据的 service.name
+	ServiceName string
+	// Exporter 为 nil 时只传播 traceparent 不导出
+	Exporter Exporter
+	// Sampler 默认 AlwaysSample
+	Sampler Sampler
+	// BatchSize 是单次导出的最大 span 数，默认 128；队列容量为其 8 倍，满时丢弃
+	BatchSize int
+	// FlushInterval 是定时导出间隔，默认 5 秒
+	FlushInterval time.Duration
+	// OnError 接收导出错误，默认忽略
+	OnError func(error)
+}
+
+// Tracer 创建 span，并在后台按批次交给 Exporter。
+type Tracer struct {
+	cfg     TracerConfig
+	queue   chan *SpanData
+	flushCh chan chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// NewTracer 创建 Tracer；配置了 Exporter 时启动后台导出协程，退出前应调用 Shutdown。
+func NewTracer(cfg TracerConfig) *Tracer {
+	if cfg.Sampler == nil {
+		cfg.Sampler = AlwaysSample
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 128
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 5 * time.Second
+	}
+	t := &Tracer{
+		cfg:     cfg,
+		flushCh: make(chan chan struct{}),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if cfg.Exporter == nil {
+		close(t.stopped)
+		return t
+	}
+	t.queue = make(chan *SpanData, cfg.BatchSize*8)
+	go t.run()
+	return t
+}
+
+// Start 创建 span：ctx 中有本地或远端父 span 时沿用其 TraceID 与采样位，否则开启新链路。
+func (t *Tracer) Start(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID, sc.Flags, sc.TraceState = parent.TraceID, parent.Flags, parent.TraceState
+	} else {
+		sc.TraceID = newTraceID()
+		if t.cfg.Sampler(sc.TraceID) {
+			sc.Flags = FlagSampled
+		}
+	}
+	s := &Span{tracer: t, sc: sc, data: SpanData{
+		Service:    t.cfg.ServiceName,
+		Name:       name,
+		Kind:       KindInternal,
+		TraceID:    sc.TraceID,
+		SpanID:     sc.SpanID,
+		TraceState: sc.TraceState,
+		Start:      time.Now(),
+	}}
+	if parent.IsValid() {
+		s.data.ParentSpanID = parent.SpanID
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return ContextWithSpan(ctx, s), s
+}
+
+func (t *Tracer) enqueue(d *SpanData) {
+	if t.queue == nil {
+		return
+	}
+	select {
+	case <-t.done:
+		return
+	default:
+	}
+	select {
+	case t.queue <- d:
+	default:
+		t.reportError(fmt.Errorf("tracing: queue full, span %q dropped", d.Name))
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.stopped)
+	ticker := time.NewTicker(t.cfg.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]SpanData, 0, t.cfg.BatchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.cfg.Exporter.Export(context.Background(), batch); err != nil {
+			t.reportError(err)
+		}
+		batch = make([]SpanData, 0, t.cfg.BatchSize)
+	}
+	drain := func() {
+		for {
+			select {
+			case d := <-t.queue:
+				batch = append(batch, *d)
+				if len(batch) >= t.cfg.BatchSize {
+					export()
+				}
+			default:
+				export()
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case d := <-t.queue:
+			batch = append(batch, *d)
+			if len(batch) >= t.cfg.BatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case ack := <-t.flushCh:
+			drain()
+			close(ack)
+		case <-t.done:
+			drain()
+			return
+		}
+	}
+}
+
+// ForceFlush 立即导出队列中已结束的 span，主要用于测试与退出前。
+func (t *Tracer) ForceFlush(ctx context.Context) error {
+	ack := make(chan struct{})
+	select {
+	case t.flushCh <- ack:
+	case <-t.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown 导出剩余 span 并关闭 Exporter，可重复调用。
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	var err error
+	t.once.Do(func() {
+		close(t.done)
+		select {
+		case <-t.stopped:
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		}
+		if t.cfg.Exporter != nil {
+			err = t.cfg.Exporter.Shutdown(ctx)
+		}
+	})
+	return err
+}
+
+func (t *Tracer) reportError(err error) {
+	if t.cfg.OnError != nil {
+		t.cfg.OnError(err)
+	}
+}
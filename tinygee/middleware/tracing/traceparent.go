@@ -0,0 +1,163 @@
+package tracing
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+)
+
+// W3C Trace Context 的请求头名。
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+// maxTracestateLen 是规范建议的 tracestate 最大长度，超出时整体丢弃而不是截断。
+const maxTracestateLen = 512
+
+// TraceID 是 16 字节的链路 ID，全零无效。
+type TraceID [16]byte
+
+// SpanID 是 8 字节的 span ID，全零无效。
+type SpanID [8]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// IsValid 判断是否非全零。
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// MarshalText 以小写十六进制编码，JSON 输出时使用。
+func (t TraceID) MarshalText() ([]byte, error) { return []byte(t.String()), nil }
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// IsValid 判断是否非全零。
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// MarshalText 以小写十六进制编码，全零时输出空串。
+func (s SpanID) MarshalText() ([]byte, error) {
+	if !s.IsValid() {
+		return []byte{}, nil
+	}
+	return []byte(s.String()), nil
+}
+
+// FlagSampled 是 trace-flags 中的采样位。
+const FlagSampled byte = 0x01
+
+// SpanContext 是跨进程传递的链路信息。
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Flags      byte
+	TraceState string
+	// Remote 表示来自上游请求头而不是本进程创建的 span
+	Remote bool
+}
+
+// IsValid 判断 TraceID 与 SpanID 都有效。
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// IsSampled 判断采样位是否置位。
+func (sc SpanContext) IsSampled() bool { return sc.Flags&FlagSampled != 0 }
+
+// Traceparent 编码为 version 00 的 traceparent 值。
+func (sc SpanContext) Traceparent() string {
+	var b strings.Builder
+	b.Grow(55)
+	b.WriteString("00-")
+	b.WriteString(sc.TraceID.String())
+	b.WriteByte('-')
+	b.WriteString(sc.SpanID.String())
+	b.WriteByte('-')
+	b.WriteString(hex.EncodeToString([]byte{sc.Flags}))
+	return b.String()
+}
+
+var errInvalidTraceparent = errors.New("tracing: invalid traceparent")
+
+// ParseTraceparent 按 W3C Trace Context 解析 traceparent。
+// version ff、大写十六进制与全零 ID 都视为无效；更高的 version 只读取前 55 个字符。
+func ParseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return sc, errInvalidTraceparent
+	}
+	version, ok := decodeHex(s[:2])
+	if !ok || version[0] == 0xff {
+		return sc, errInvalidTraceparent
+	}
+	if (version[0] == 0 && len(s) != 55) || (version[0] > 0 && len(s) > 55 && s[55] != '-') {
+		return sc, errInvalidTraceparent
+	}
+	traceID, ok1 := decodeHex(s[3:35])
+	spanID, ok2 := decodeHex(s[36:52])
+	flags, ok3 := decodeHex(s[53:55])
+	if !ok1 || !ok2 || !ok3 {
+		return sc, errInvalidTraceparent
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return SpanContext{}, errInvalidTraceparent
+	}
+	return sc, nil
+}
+
+// decodeHex 只接受小写十六进制。
+func decodeHex(s string) ([]byte, bool) {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return nil, false
+		}
+	}
+	b, err := hex.DecodeString(s)
+	return b, err == nil
+}
+
+// Extract 从请求头读取上游的链路信息，traceparent 无效时忽略 tracestate。
+func Extract(h http.Header) (SpanContext, bool) {
+	sc, err := ParseTraceparent(strings.TrimSpace(h.Get(TraceparentHeader)))
+	if err != nil {
+		return SpanContext{}, false
+	}
+	if ts := strings.Join(h.Values(TracestateHeader), ","); len(ts) <= maxTracestateLen {
+		sc.TraceState = strings.TrimSpace(ts)
+	}
+	sc.Remote = true
+	return sc, true
+}
+
+// InjectSpanContext 把链路信息写入请求头，用于调用下游服务。
+func InjectSpanContext(sc SpanContext, h http.Header) {
+	if !sc.IsValid() {
+		return
+	}
+	h.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		h.Set(TracestateHeader, sc.TraceState)
+	} else {
+		h.Del(TracestateHeader)
+	}
+}
+
+func newTraceID() TraceID {
+	var t TraceID
+	for !t.IsValid() {
+		binary.BigEndian.PutUint64(t[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(t[8:], rand.Uint64())
+	}
+	return t
+}
+
+func newSpanID() SpanID {
+	var s SpanID
+	for !s.IsValid() {
+		binary.BigEndian.PutUint64(s[:], rand.Uint64())
+	}
+	return s
+}
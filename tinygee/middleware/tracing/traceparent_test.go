@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	const valid = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceparent(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" || !sc.IsSampled() {
+		t.Fatalf("unexpected %+v", sc)
+	}
+	if sc.Traceparent() != valid {
+		t.Fatalf("round trip: %s", sc.Traceparent())
+	}
+	// 未来版本允许在末尾追加字段
+	if _, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); err != nil {
+		t.Fatalf("future version: %v", err)
+	}
+
+	for _, bad := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0x",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(bad); err == nil {
+			t.Fatalf("%q should be rejected", bad)
+		}
+	}
+}
+
+func TestExtractInject(t *testing.T) {
+	h := http.Header{}
+	h.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	h.Add(TracestateHeader, "congo=t61rcWkgMzE")
+	h.Add(TracestateHeader, "rojo=00f067aa0ba902b7")
+	sc, ok := Extract(h)
+	if !ok || !sc.Remote || sc.IsSampled() || sc.TraceState != "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7" {
+		t.Fatalf("extract: %+v %v", sc, ok)
+	}
+
+	out := http.Header{}
+	InjectSpanContext(sc, out)
+	if out.Get(TraceparentHeader) != h.Get(TraceparentHeader) || out.Get(TracestateHeader) != sc.TraceState {
+		t.Fatalf("inject: %v", out)
+	}
+
+	h.Set(TraceparentHeader, "garbage")
+	if _, ok := Extract(h); ok {
+		t.Fatal("invalid traceparent should be ignored")
+	}
+}
+
+func TestTraceIDRatio(t *testing.T) {
+	sampler := TraceIDRatio(0.25)
+	hits := 0
+	for i := 0; i < 10000; i++ {
+		id := newTraceID()
+		if sampler(id) != sampler(id) {
+			t.Fatal("sampling must be deterministic per trace")
+		}
+		if sampler(id) {
+			hits++
+		}
+	}
+	if hits < 2000 || hits > 3000 {
+		t.Fatalf("ratio 0.25 sampled %d/10000", hits)
+	}
+}
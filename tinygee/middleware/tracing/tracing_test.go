@@ -0,0 +1,167 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+// memoryExporter 在内存中收集导出的 span。
+type memoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (m *memoryExporter) Export(_ context.Context, spans []SpanData) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.spans = append(m.spans, spans...)
+	return nil
+}
+
+func (m *memoryExporter) Shutdown(context.Context) error { return nil }
+
+func (m *memoryExporter) byName(t *testing.T, tr *Tracer) map[string]SpanData {
+	t.Helper()
+	if err := tr.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]SpanData, len(m.spans))
+	for _, s := range m.spans {
+		out[s.Name] = s
+	}
+	return out
+}
+
+func TestMiddlewareJoinsUpstreamTrace(t *testing.T) {
+	exp := &memoryExporter{}
+	tr := NewTracer(TracerConfig{ServiceName: "todo", Exporter: exp})
+	defer tr.Shutdown(context.Background())
+
+	app := tinygee.New()
+	app.Use(New(tr))
+	app.GET("/users/:id", func(c *tinygee.Context) {
+		_, span := Start(c.Req.Context(), "load user")
+		span.SetAttr("user.id", c.Param("id"))
+		span.End()
+		c.Error(errors.New("cache miss"))
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+	req.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	app.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exp.byName(t, tr)
+	server, ok := spans["GET /users/:id"]
+	if !ok {
+		t.Fatalf("server span missing: %v", spans)
+	}
+	if server.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || server.ParentSpanID.String() != "00f067aa0ba902b7" {
+		t.Fatalf("server span not joined to upstream: %+v", server)
+	}
+	if server.Kind != KindServer || server.Service != "todo" || server.Status != StatusError {
+		t.Fatalf("unexpected server span %+v", server)
+	}
+	child := spans["load user"]
+	if child.TraceID != server.TraceID || child.ParentSpanID != server.SpanID || child.Kind != KindInternal {
+		t.Fatalf("child span not parented: %+v", child)
+	}
+	if child.Attrs[0] != (Attr{Key: "user.id", Value: "7"}) {
+		t.Fatalf("child attrs %v", child.Attrs)
+	}
+}
+
+func TestMiddlewareUnsampledStillPropagates(t *testing.T) {
+	exp := &memoryExporter{}
+	tr := NewTracer(TracerConfig{Exporter: exp, Sampler: NeverSample})
+	defer tr.Shutdown(context.Background())
+
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get(TraceparentHeader)))
+	}))
+	defer downstream.Close()
+	client := &http.Client{Transport: Transport(nil)}
+
+	app := tinygee.New()
+	app.Use(New(tr))
+	var traceID string
+	app.GET("/proxy", func(c *tinygee.Context) {
+		traceID = SpanFromContext(c.Req.Context()).SpanContext().TraceID.String()
+		req, _ := http.NewRequestWithContext(c.Req.Context(), http.MethodGet, downstream.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			c.AbortWithError(http.StatusBadGateway, err)
+			return
+		}
+		defer resp.Body.Close()
+		var buf bytes.Buffer
+		buf.ReadFrom(resp.Body)
+		c.String(http.StatusOK, "%s", buf.String())
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/proxy", nil))
+	sc, err := ParseTraceparent(w.Body.String())
+	if err != nil || sc.TraceID.String() != traceID || sc.IsSampled() {
+		t.Fatalf("downstream got %q (err %v), want trace %s unsampled", w.Body.String(), err, traceID)
+	}
+	if spans := exp.byName(t, tr); len(spans) != 0 {
+		t.Fatalf("unsampled spans must not be exported: %v", spans)
+	}
+}
+
+func TestHandlerUsesServeMuxPattern(t *testing.T) {
+	var buf bytes.Buffer
+	tr := NewTracer(TracerConfig{ServiceName: "todoapi", Exporter: NewJSONExporter(&buf)})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/todos/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if SpanFromContext(r.Context()) == nil {
+			t.Error("span missing from request context")
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	Handler(tr, mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/todos/3", nil))
+	if err := tr.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	if got["name"] != "GET /v1/todos/{id}" || got["status"] != float64(StatusError) || got["service"] != "todoapi" {
+		t.Fatalf("unexpected span %v", got)
+	}
+	if _, ok := got["parent_span_id"]; ok {
+		t.Fatalf("root span should omit parent: %v", got)
+	}
+	if !strings.Contains(buf.String(), `"http.response.status_code","value":500`) {
+		t.Fatalf("status attr missing: %s", buf.String())
+	}
+}
+
+func TestNilSpanIsNoop(t *testing.T) {
+	ctx, span := Start(context.Background(), "orphan")
+	if span != nil || SpanFromContext(ctx) != nil {
+		t.Fatal("Start without parent should return nil span")
+	}
+	span.SetAttr("k", "v")
+	span.RecordError(errors.New("x"))
+	span.End()
+	h := http.Header{}
+	Inject(ctx, h)
+	if len(h) != 0 {
+		t.Fatalf("nothing to inject, got %v", h)
+	}
+}
@@ -0,0 +1,67 @@
+package tinygee
+
+import (
+	"net/http"
+	"strings"
+)
+
+// mountParam 是 Mount 注册的通配参数名，保存去掉挂载前缀后的剩余路径。
+const mountParam = "mountpath"
+
+// WrapH 把 http.Handler 适配为 HandlerFunc。
+func WrapH(h http.Handler) HandlerFunc {
+	return func(c *Context) {
+		h.ServeHTTP(c.Writer, c.Req)
+	}
+}
+
+// WrapF 把 http.HandlerFunc 适配为 HandlerFunc。
+func WrapF(f http.HandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		f(c.Writer, c.Req)
+	}
+}
+
+// Mount 把 h 挂载到 prefix 下，所有方法的请求在去掉分组前缀与 prefix 后交给 h。
+// h 为 *Engine 时作为子引擎运行：先经过本分组的中间件，再走子引擎自己的中间件与路由，
+// 并继承父请求通过 Set 写入的键值。
+func (g *RouterGroup) Mount(prefix string, h http.Handler) {
+	var handler HandlerFunc
+	if sub, ok := h.(*Engine); ok {
+		handler = func(c *Context) { sub.serveSub(c, strippedRequest(c)) }
+	} else {
+		handler = func(c *Context) { h.ServeHTTP(c.Writer, strippedRequest(c)) }
+	}
+	g.Any(prefix, handler)
+	g.Any(strings.TrimRight(prefix, "/")+"/*"+mountParam, handler)
+}
+
+// Mount 把 h 挂载到根分组的 prefix 下。
+func (e *Engine) Mount(prefix string, h http.Handler) {
+	e.root.Mount(prefix, h)
+}
+
+// strippedRequest 复制请求并把路径改写为挂载点之后的部分。
+func strippedRequest(c *Context) *http.Request {
+	req := new(http.Request)
+	*req = *c.Req
+	u := *c.Req.URL
+	u.Path = "/" + c.Param(mountParam)
+	u.RawPath = ""
+	req.URL = &u
+	return req
+}
+
+// serveSub 以父请求的 ResponseWriter 运行子引擎，子引擎写出的状态码与字节数对父引擎的中间件可见。
+func (e *Engine) serveSub(parent *Context, req *http.Request) {
+	c := e.pool.Get().(*Context)
+	c.reset(parent.Writer, req)
+	parent.mu.RLock()
+	for k, v := range parent.Keys {
+		c.Set(k, v)
+	}
+	parent.mu.RUnlock()
+	e.handle(c)
+	c.Writer.WriteHeaderNow()
+	e.pool.Put(c)
+}
@@ -0,0 +1,139 @@
+package tinygee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serve(e *Engine, method, target, host string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if host != "" {
+		req.Host = host
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func TestMountHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery))
+	})
+
+	r := New()
+	var seen []string
+	legacy := r.Group("/legacy")
+	legacy.Use(func(c *Context) {
+		seen = append(seen, c.Path)
+		c.Next()
+	})
+	legacy.Mount("/v1", mux)
+	r.GET("/wrapped", WrapF(func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("f")) }))
+	r.GET("/wrappedh", WrapH(mux))
+
+	cases := []struct {
+		method, target, body string
+	}{
+		{http.MethodGet, "/legacy/v1", "GET /?"},
+		{http.MethodGet, "/legacy/v1/todos/3?x=1", "GET /todos/3?x=1"},
+		{http.MethodDelete, "/legacy/v1/todos/3", "DELETE /todos/3?"},
+		{http.MethodGet, "/wrapped", "f"},
+		{http.MethodGet, "/wrappedh", "GET /wrappedh?"},
+	}
+	for _, tc := range cases {
+		if w := serve(r, tc.method, tc.target, ""); w.Body.String() != tc.body {
+			t.Errorf("%s %s: got %d %q, want %q", tc.method, tc.target, w.Code, w.Body.String(), tc.body)
+		}
+	}
+	if len(seen) != 3 {
+		t.Fatalf("group middleware should run for mounted requests only, saw %v", seen)
+	}
+}
+
+func TestMountSubEngine(t *testing.T) {
+	sub := New()
+	sub.Use(func(c *Context) {
+		c.SetHeader("X-Sub", "1")
+		c.Next()
+	})
+	sub.GET("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "user %s by %s", c.Param("id"), c.GetString("who"))
+	})
+
+	r := New()
+	var status int
+	r.Use(func(c *Context) {
+		c.Next()
+		status = c.Writer.Status()
+	})
+	admin := r.Group("/admin")
+	admin.Use(func(c *Context) {
+		c.Set("who", "root")
+		c.Next()
+	})
+	admin.Mount("/", sub)
+
+	w := serve(r, http.MethodGet, "/admin/users/7", "")
+	if w.Code != http.StatusOK || w.Body.String() != "user 7 by root" || w.Header().Get("X-Sub") != "1" {
+		t.Fatalf("sub engine: %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+
+	w = serve(r, http.MethodGet, "/admin/nope", "")
+	if w.Code != http.StatusNotFound || status != http.StatusNotFound {
+		t.Fatalf("sub engine 404: code=%d parent saw %d", w.Code, status)
+	}
+}
+
+func TestHostRouting(t *testing.T) {
+	r := New()
+	var global int
+	r.Use(func(c *Context) {
+		global++
+		c.Next()
+	})
+	r.GET("/", func(c *Context) { c.String(http.StatusOK, "default") })
+
+	admin := r.Host("Admin.Example.com")
+	var adminMW int
+	admin.Use(func(c *Context) {
+		adminMW++
+		c.Next()
+	})
+	admin.GET("/", func(c *Context) { c.String(http.StatusOK, "admin") })
+	admin.Group("/users").GET("/:id", func(c *Context) { c.String(http.StatusOK, "admin user %s", c.Param("id")) })
+	r.Host("*.tenant.example.com").GET("/", func(c *Context) { c.String(http.StatusOK, "tenant %s", c.Req.Host) })
+
+	cases := []struct {
+		host, target string
+		code         int
+		body         string
+	}{
+		{"example.com", "/", http.StatusOK, "default"},
+		{"admin.example.com", "/", http.StatusOK, "admin"},
+		{"ADMIN.example.com:8080", "/users/3", http.StatusOK, "admin user 3"},
+		{"a.tenant.example.com", "/", http.StatusOK, "tenant a.tenant.example.com"},
+		{"tenant.example.com", "/", http.StatusOK, "default"},
+		{"example.com", "/users/3", http.StatusNotFound, ""},
+		{"admin.example.com", "/missing", http.StatusNotFound, ""},
+	}
+	for _, tc := range cases {
+		w := serve(r, http.MethodGet, tc.target, tc.host)
+		if w.Code != tc.code || (tc.body != "" && w.Body.String() != tc.body) {
+			t.Errorf("%s%s: got %d %q", tc.host, tc.target, w.Code, w.Body.String())
+		}
+	}
+	if global != len(cases) || adminMW != 2 {
+		t.Fatalf("middleware counts: global=%d admin=%d", global, adminMW)
+	}
+
+	var hosts []string
+	for _, info := range r.Routes() {
+		hosts = append(hosts, info.Host)
+	}
+	if got := strings.Join(hosts, ","); got != ",admin.example.com,admin.example.com,*.tenant.example.com" {
+		t.Fatalf("unexpected route hosts %q", got)
+	}
+}
@@ -0,0 +1,156 @@
+package tinygee
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// cleanPath 规范化 URL 路径：合并重复的 /，解析 . 与 ..，保留末尾的 /。
+// 与 path.Clean 不同，结果总以 / 开头，且不会越过根目录。
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	trailing := len(p) > 1 && p[len(p)-1] == '/'
+	segs := strings.Split(p, "/")
+	out := make([]string, 0, len(segs))
+	for _, seg := range segs {
+		switch seg {
+		case "", ".":
+		case "..":
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+		default:
+			out = append(out, seg)
+		}
+	}
+	cleaned := "/" + strings.Join(out, "/")
+	if trailing && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// toggleTrailingSlash 在有无末尾 / 之间切换。
+func toggleTrailingSlash(p string) string {
+	if strings.HasSuffix(p, "/") {
+		return p[:len(p)-1]
+	}
+	return p + "/"
+}
+
+// matchFold 忽略静态段大小写查找路由，返回按注册时大小写修正后的路径。
+// 参数段与通配段保留请求中的原样；未命中返回 nil。
+func (n *node) matchFold(path string, buf []byte) []byte {
+	if path == "" {
+		if n.route != nil {
+			return buf
+		}
+		return nil
+	}
+	for _, child := range n.children {
+		l := len(child.path)
+		if len(path) >= l && strings.EqualFold(path[:l], child.path) {
+			if out := child.matchFold(path[l:], append(buf, child.path...)); out != nil {
+				return out
+			}
+		}
+	}
+	if len(n.params) > 0 {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			seg := path[:end]
+			for _, p := range n.params {
+				if p.constraint != nil && !p.constraint.match(seg) {
+					continue
+				}
+				if out := p.matchFold(path[end:], append(buf, seg...)); out != nil {
+					return out
+				}
+			}
+		}
+	}
+	if n.catchAll != nil && n.catchAll.route != nil {
+		return append(buf, path...)
+	}
+	return nil
+}
+
+// find 查找路由，未显式注册 HEAD 时回退到 GET。
+func (r *router) find(method, path string, values *[]string) *route {
+	rt := r.lookup(method, path, values)
+	if rt == nil && method == http.MethodHead {
+		rt = r.lookup(http.MethodGet, path, values)
+	}
+	return rt
+}
+
+// findFold 是 find 的大小写不敏感版本，返回修正后的路径。
+func (r *router) findFold(method, path string) (string, bool) {
+	for _, m := range []string{method, http.MethodGet} {
+		if root, ok := r.roots[m]; ok {
+			if out := root.matchFold(path, make([]byte, 0, len(path))); out != nil {
+				return string(out), true
+			}
+		}
+		if method != http.MethodHead {
+			break
+		}
+	}
+	return "", false
+}
+
+// redirectPath 按引擎的重定向选项寻找能命中路由的替代路径。
+func (r *router) redirectPath(e *Engine, method, path string, values *[]string) (string, bool) {
+	exists := func(p string) bool {
+		found := r.find(method, p, values) != nil
+		*values = (*values)[:0]
+		return found
+	}
+	if e.RedirectTrailingSlash {
+		if alt := toggleTrailingSlash(path); exists(alt) {
+			return alt, true
+		}
+	}
+	if !e.RedirectFixedPath {
+		return "", false
+	}
+	candidates := []string{cleanPath(path)}
+	if e.RedirectTrailingSlash && candidates[0] != "/" {
+		candidates = append(candidates, toggleTrailingSlash(candidates[0]))
+	}
+	for _, p := range candidates {
+		if p != path && exists(p) {
+			return p, true
+		}
+		if fixed, ok := r.findFold(method, p); ok && fixed != path {
+			return fixed, true
+		}
+	}
+	return "", false
+}
+
+// redirectTo 发出路径修正重定向：GET/HEAD 使用 301，其余方法使用 308 以保留方法与请求体。
+// escaped 表示 p 已是转义后的原始路径。
+func redirectTo(c *Context, p string, escaped bool) {
+	code := http.StatusPermanentRedirect
+	if c.Method == http.MethodGet || c.Method == http.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+	// 合并开头的多个 /，避免 //evil.com 被浏览器当成协议相对地址
+	p = "/" + strings.TrimLeft(p, "/")
+	if !escaped {
+		p = (&url.URL{Path: p}).EscapedPath()
+	}
+	if q := c.Req.URL.RawQuery; q != "" {
+		p += "?" + q
+	}
+	c.SetHeader("Location", p)
+	c.Status(code)
+	c.Writer.WriteHeaderNow()
+}
@@ -0,0 +1,99 @@
+package tinygee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCleanPath(t *testing.T) {
+	cases := map[string]string{
+		"":                "/",
+		"/":               "/",
+		"//hello":         "/hello",
+		"/a//b/":          "/a/b/",
+		"/a/./b":          "/a/b",
+		"/a/../hello":     "/hello",
+		"/../../etc":      "/etc",
+		"hello/world":     "/hello/world",
+		"/a/b/../../c/./": "/c/",
+	}
+	for in, want := range cases {
+		if got := cleanPath(in); got != want {
+			t.Errorf("cleanPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRedirects(t *testing.T) {
+	r := New()
+	r.RedirectFixedPath = true
+	noop := func(c *Context) { c.String(http.StatusOK, "ok") }
+	r.GET("/hello", noop)
+	r.POST("/items", noop)
+	r.GET("/users/:id<int>/Profile", noop)
+	r.GET("/evil.com", noop)
+
+	cases := []struct {
+		method   string
+		target   string
+		code     int
+		location string
+	}{
+		{http.MethodGet, "/hello", http.StatusOK, ""},
+		{http.MethodGet, "/hello/", http.StatusMovedPermanently, "/hello"},
+		{http.MethodHead, "/hello/", http.StatusMovedPermanently, "/hello"},
+		{http.MethodGet, "/hello/?a=1", http.StatusMovedPermanently, "/hello?a=1"},
+		{http.MethodPost, "/items/", http.StatusPermanentRedirect, "/items"},
+		{http.MethodGet, "//hello", http.StatusMovedPermanently, "/hello"},
+		{http.MethodGet, "/x/../hello", http.StatusMovedPermanently, "/hello"},
+		{http.MethodGet, "/HeLLo", http.StatusMovedPermanently, "/hello"},
+		{http.MethodGet, "/HELLO/", http.StatusMovedPermanently, "/hello"},
+		{http.MethodGet, "/USERS/42/profile", http.StatusMovedPermanently, "/users/42/Profile"},
+		{http.MethodGet, "/USERS/abc/profile", http.StatusNotFound, ""},
+		{http.MethodGet, "//evil.com/", http.StatusMovedPermanently, "/evil.com"},
+		{http.MethodGet, "/missing/", http.StatusNotFound, ""},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.target, nil))
+		if w.Code != tc.code || w.Header().Get("Location") != tc.location {
+			t.Errorf("%s %s: got %d %q, want %d %q", tc.method, tc.target,
+				w.Code, w.Header().Get("Location"), tc.code, tc.location)
+		}
+	}
+
+	r.RedirectTrailingSlash = false
+	r.RedirectFixedPath = false
+	for _, target := range []string{"/hello/", "//hello", "/HELLO"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s with redirects disabled: got %d", target, w.Code)
+		}
+	}
+}
+
+func TestUseRawPath(t *testing.T) {
+	r := New()
+	r.GET("/files/:name", func(c *Context) { c.String(http.StatusOK, "%s", c.Param("name")) })
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/a%2Fb%20c", nil))
+		return w
+	}
+	// 默认按解码后的路径匹配，a/b c 被拆成两段
+	if w := get(); w.Code != http.StatusNotFound {
+		t.Fatalf("decoded path should not match, got %d", w.Code)
+	}
+
+	r.UseRawPath = true
+	if w := get(); w.Code != http.StatusOK || w.Body.String() != "a/b c" {
+		t.Fatalf("raw path: %d %q", w.Code, w.Body.String())
+	}
+	r.UnescapePathValues = false
+	if w := get(); w.Body.String() != "a%2Fb%20c" {
+		t.Fatalf("raw value: %q", w.Body.String())
+	}
+}
@@ -1,44 +1,309 @@
 package render
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"html/template"
+	"io"
+	"io/fs"
 	"net/http"
+	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/xrjjing/Learn4Go/tinygee"
 )
 
-// TemplateRenderer 支持 FuncMap 与模板加载。
+// TemplateRenderer 支持 FuncMap、布局继承、局部模板与开发期热加载。
 type TemplateRenderer struct {
-	templates *template.Template
+	engine   *tinygee.Engine
+	funcs    template.FuncMap
+	layouts  string
+	partials string
+	ext      string
+	dev      bool
+
+	// parse 完整解析一次模板，返回模板名到所属模板集的映射
+	parse func() (map[string]*template.Template, error)
+	// stamp 汇总模板文件的名称、大小与修改时间，开发模式下用于判断是否需要重新解析
+	stamp func() (string, error)
+
+	mu    sync.RWMutex
+	sets  map[string]*template.Template
+	state string
+}
+
+// Option 配置 TemplateRenderer。
+type Option func(*TemplateRenderer)
+
+// WithEngine 让内置的 url 模板函数通过 engine 按路由名生成路径，
+// 用法：{{ url "user.show" "id" .ID }}。
+func WithEngine(e *tinygee.Engine) Option {
+	return func(tr *TemplateRenderer) { tr.engine = e }
+}
+
+// WithFuncs 追加模板函数，同名时覆盖内置函数与先前注册的函数。
+func WithFuncs(funcMap template.FuncMap) Option {
+	return func(tr *TemplateRenderer) {
+		for k, v := range funcMap {
+			tr.funcs[k] = v
+		}
+	}
+}
+
+// WithLayoutDir 设置 NewFS 的布局目录，默认 "layouts"。
+func WithLayoutDir(dir string) Option {
+	return func(tr *TemplateRenderer) { tr.layouts = dir }
+}
+
+// WithPartialDir 设置 NewFS 的局部模板目录，默认 "partials"。
+func WithPartialDir(dir string) Option {
+	return func(tr *TemplateRenderer) { tr.partials = dir }
+}
+
+// WithExtension 设置 NewFS 加载的模板扩展名，默认 ".html"。
+func WithExtension(ext string) Option {
+	return func(tr *TemplateRenderer) { tr.ext = ext }
+}
+
+// WithDevMode 开启后每次渲染前检查模板文件，有变化时重新解析，无需重启进程。
+// embed.FS 没有修改时间，开发时应改用 os.DirFS 指向源码目录。
+func WithDevMode(on bool) Option {
+	return func(tr *TemplateRenderer) { tr.dev = on }
+}
+
+func newRenderer(opts []Option) *TemplateRenderer {
+	tr := &TemplateRenderer{layouts: "layouts", partials: "partials", ext: ".html"}
+	tr.funcs = template.FuncMap{"url": tr.url}
+	for _, opt := range opts {
+		opt(tr)
+	}
+	return tr
 }
 
-// New 创建模板渲染器。
-func New(glob string, funcMap template.FuncMap) (*TemplateRenderer, error) {
-	t := template.New(filepath.Base(glob)).Funcs(funcMap)
-	parsed, err := t.ParseGlob(glob)
+// New 按 glob 加载模板，模板以文件名引用；funcMap 中的同名函数会覆盖内置函数。
+func New(glob string, funcMap template.FuncMap, opts ...Option) (*TemplateRenderer, error) {
+	tr := newRenderer(append([]Option{WithFuncs(funcMap)}, opts...))
+	tr.parse = func() (map[string]*template.Template, error) {
+		t, err := template.New(filepath.Base(glob)).Funcs(tr.funcs).ParseGlob(glob)
+		if err != nil {
+			return nil, err
+		}
+		return indexSet(nil, t), nil
+	}
+	tr.stamp = func() (string, error) {
+		files, err := filepath.Glob(glob)
+		if err != nil {
+			return "", err
+		}
+		var sb strings.Builder
+		for _, f := range files {
+			fi, err := os.Stat(f)
+			if err != nil {
+				return "", err
+			}
+			writeStamp(&sb, f, fi)
+		}
+		return sb.String(), nil
+	}
+	return tr, tr.Reload()
+}
+
+// NewFS 从 fsys 加载全部模板，模板以相对路径引用（如 "users/show.html"）。
+// 布局目录与局部模板目录中的文件对所有页面可见；其余每个文件是一个页面，
+// 在布局的副本上单独解析，因此不同页面可以各自 define 同名 block 而互不覆盖：
+//
+//	{{template "layouts/base.html" .}}
+//	{{define "content"}}...{{end}}
+//
+// 配合 embed.FS 使用时模板会编译进二进制。
+func NewFS(fsys fs.FS, opts ...Option) (*TemplateRenderer, error) {
+	tr := newRenderer(opts)
+	tr.parse = func() (map[string]*template.Template, error) { return tr.parseFS(fsys) }
+	tr.stamp = func() (string, error) {
+		var sb strings.Builder
+		err := tr.walk(fsys, func(p string, d fs.DirEntry) error {
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+			writeStamp(&sb, p, fi)
+			return nil
+		})
+		return sb.String(), err
+	}
+	return tr, tr.Reload()
+}
+
+func writeStamp(sb *strings.Builder, name string, fi fs.FileInfo) {
+	fmt.Fprintf(sb, "%s:%d:%d;", name, fi.Size(), fi.ModTime().UnixNano())
+}
+
+// walk 按字典序遍历 fsys 中扩展名匹配的文件。
+func (tr *TemplateRenderer) walk(fsys fs.FS, fn func(p string, d fs.DirEntry) error) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != tr.ext {
+			return nil
+		}
+		return fn(p, d)
+	})
+}
+
+func (tr *TemplateRenderer) parseFS(fsys fs.FS) (map[string]*template.Template, error) {
+	var shared, pages []string
+	err := tr.walk(fsys, func(p string, _ fs.DirEntry) error {
+		if inDir(p, tr.layouts) || inDir(p, tr.partials) {
+			shared = append(shared, p)
+		} else {
+			pages = append(pages, p)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &TemplateRenderer{templates: parsed}, nil
+	if len(shared)+len(pages) == 0 {
+		return nil, fmt.Errorf("render: no %s templates found", tr.ext)
+	}
+
+	base := template.New("").Funcs(tr.funcs)
+	for _, p := range shared {
+		if err := parseFile(base, fsys, p); err != nil {
+			return nil, err
+		}
+	}
+	sets := indexSet(nil, base)
+	for _, p := range pages {
+		// 必须在任何模板执行前 Clone，html/template 不允许克隆已执行的模板集
+		set, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if err := parseFile(set, fsys, p); err != nil {
+			return nil, err
+		}
+		sets[p] = set
+	}
+	return sets, nil
 }
 
-// HTML 渲染模板。
-func (tr *TemplateRenderer) HTML(c *tinygee.Context, code int, name string, data any) {
-	c.SetHeader("Content-Type", "text/html; charset=utf-8")
-	c.Status(code)
-	_ = tr.templates.ExecuteTemplate(c.Writer, name, data)
+func parseFile(t *template.Template, fsys fs.FS, name string) error {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	if _, err := t.New(name).Parse(string(b)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func inDir(p, dir string) bool {
+	return dir != "" && strings.HasPrefix(p, strings.Trim(dir, "/")+"/")
+}
+
+// indexSet 把模板集中的每个命名模板指向该模板集。
+func indexSet(sets map[string]*template.Template, t *template.Template) map[string]*template.Template {
+	if sets == nil {
+		sets = make(map[string]*template.Template)
+	}
+	for _, sub := range t.Templates() {
+		if sub.Name() != "" {
+			sets[sub.Name()] = t
+		}
+	}
+	return sets
+}
+
+// Reload 立即重新解析全部模板；解析失败时保留旧模板并返回错误。
+func (tr *TemplateRenderer) Reload() error {
+	state, err := tr.stamp()
+	if err != nil {
+		return err
+	}
+	sets, err := tr.parse()
+	if err != nil {
+		return err
+	}
+	tr.mu.Lock()
+	tr.sets, tr.state = sets, state
+	tr.mu.Unlock()
+	return nil
+}
+
+// Names 返回可渲染的模板名，按字典序排列。
+func (tr *TemplateRenderer) Names() []string {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+	names := make([]string, 0, len(tr.sets))
+	for name := range tr.sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
-// Static 返回一个处理静态文件的 HandlerFunc。
-func Static(relative string, root http.FileSystem) tinygee.HandlerFunc {
-	fileServer := http.StripPrefix(relative, http.FileServer(root))
-	return func(c *tinygee.Context) {
-		// 简单安全防护：仅允许 GET
-		if c.Method != http.MethodGet {
-			c.Status(http.StatusMethodNotAllowed)
-			return
+func (tr *TemplateRenderer) lookup(name string) (*template.Template, error) {
+	if tr.dev {
+		state, err := tr.stamp()
+		if err != nil {
+			return nil, err
 		}
-		fileServer.ServeHTTP(c.Writer, c.Req)
+		tr.mu.RLock()
+		changed := state != tr.state
+		tr.mu.RUnlock()
+		if changed {
+			if err := tr.Reload(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	tr.mu.RLock()
+	set := tr.sets[name]
+	tr.mu.RUnlock()
+	if set == nil {
+		return nil, fmt.Errorf("render: template %q not defined", name)
+	}
+	return set, nil
+}
+
+// url 是内置模板函数，参数值按 fmt.Sprint 转成字符串，模板里可以直接传 int 等类型；
+// 未配置 WithEngine 时执行报错。
+func (tr *TemplateRenderer) url(name string, kv ...any) (string, error) {
+	if tr.engine == nil {
+		return "", errors.New("render: url func requires WithEngine")
 	}
+	args := make([]string, len(kv))
+	for i, v := range kv {
+		args[i] = fmt.Sprint(v)
+	}
+	return tr.engine.URL(name, args...)
+}
+
+// Execute 把模板 name 渲染到 w，可用于邮件等非 HTTP 场景。
+func (tr *TemplateRenderer) Execute(w io.Writer, name string, data any) error {
+	set, err := tr.lookup(name)
+	if err != nil {
+		return err
+	}
+	return set.ExecuteTemplate(w, name, data)
+}
+
+// HTML 渲染模板。先写入缓冲区，执行出错时不会输出半截页面，
+// 而是以 ErrorTypeRender 记录错误并交给 Engine.ErrorHandler 返回 500。
+func (tr *TemplateRenderer) HTML(c *tinygee.Context, code int, name string, data any) {
+	var buf bytes.Buffer
+	if err := tr.Execute(&buf, name, data); err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err).SetType(tinygee.ErrorTypeRender)
+		return
+	}
+	c.SetHeader("Content-Type", "text/html; charset=utf-8")
+	c.Data(code, buf.Bytes())
 }
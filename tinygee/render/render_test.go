@@ -1,11 +1,16 @@
 package render
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"text/template"
+	"time"
 
 	"github.com/xrjjing/Learn4Go/tinygee"
 )
@@ -45,3 +50,132 @@ func TestStatic(t *testing.T) {
 		t.Fatalf("unexpected body %s", w.Body.String())
 	}
 }
+
+func TestTemplateURLFunc(t *testing.T) {
+	r := tinygee.New()
+	r.GET("/users/:id", func(c *tinygee.Context) {}).Name("user.show")
+	tr, err := New("testdata/*.html", template.FuncMap{"upper": strings.ToUpper}, WithEngine(r))
+	if err != nil {
+		t.Fatalf("load template: %v", err)
+	}
+	r.GET("/link", func(c *tinygee.Context) {
+		tr.HTML(c, http.StatusOK, "link.html", map[string]any{"ID": "42"})
+	})
+	// 模型里的 ID 通常是整数，不需要在模板里先转成字符串
+	r.GET("/link/int", func(c *tinygee.Context) {
+		tr.HTML(c, http.StatusOK, "link.html", map[string]any{"ID": 42})
+	})
+
+	for _, path := range []string{"/link", "/link/int"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if body := w.Body.String(); w.Code != http.StatusOK || !strings.Contains(body, `href="/users/42?tab=posts"`) {
+			t.Fatalf("%s: unexpected %d %s", path, w.Code, body)
+		}
+	}
+}
+
+var layoutFS = fstest.MapFS{
+	"layouts/base.html": {Data: []byte(`<title>{{block "title" .}}TinyGee{{end}}</title>{{template "partials/nav.html" .}}<main>{{block "content" .}}{{end}}</main>`)},
+	"partials/nav.html": {Data: []byte(`<nav>{{.User}}</nav>`)},
+	"index.html":        {Data: []byte(`{{template "layouts/base.html" .}}{{define "title"}}Home{{end}}{{define "content"}}welcome{{end}}`)},
+	"users/show.html":   {Data: []byte(`{{template "layouts/base.html" .}}{{define "content"}}user {{.User}}{{end}}`)},
+	"broken.html":       {Data: []byte(`<p>partial output</p>{{fail}}`)},
+}
+
+func TestNewFSLayouts(t *testing.T) {
+	tr, err := NewFS(layoutFS, WithFuncs(template.FuncMap{
+		"fail": func() (string, error) { return "", errors.New("boom") },
+	}))
+	if err != nil {
+		t.Fatalf("load templates: %v", err)
+	}
+	cases := map[string]string{
+		"index.html":      `<title>Home</title><nav>go</nav><main>welcome</main>`,
+		"users/show.html": `<title>TinyGee</title><nav>go</nav><main>user go</main>`,
+		// 局部模板也可以单独渲染
+		"partials/nav.html": `<nav>go</nav>`,
+	}
+	for name, want := range cases {
+		var sb strings.Builder
+		if err := tr.Execute(&sb, name, map[string]string{"User": "go"}); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if sb.String() != want {
+			t.Fatalf("%s: got %q want %q", name, sb.String(), want)
+		}
+	}
+}
+
+func TestHTMLSurfacesExecError(t *testing.T) {
+	tr, err := NewFS(layoutFS, WithFuncs(template.FuncMap{
+		"fail": func() (string, error) { return "", errors.New("boom") },
+	}))
+	if err != nil {
+		t.Fatalf("load templates: %v", err)
+	}
+	r := tinygee.New()
+	var got tinygee.ErrorList
+	r.Use(func(c *tinygee.Context) {
+		c.Next()
+		got = c.Errors
+	})
+	r.GET("/page/*name", func(c *tinygee.Context) {
+		tr.HTML(c, http.StatusOK, c.Param("name"), nil)
+	})
+
+	for _, name := range []string{"broken.html", "missing.html"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/page/"+name, nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("%s: status %d", name, w.Code)
+		}
+		if strings.Contains(w.Body.String(), "partial output") {
+			t.Fatalf("%s: half-rendered page leaked: %s", name, w.Body.String())
+		}
+		if len(got) != 1 || !got[0].IsType(tinygee.ErrorTypeRender) {
+			t.Fatalf("%s: errors %v", name, got)
+		}
+	}
+}
+
+func TestDevModeReload(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "index.html")
+	write := func(body string, mod time.Time) {
+		t.Helper()
+		if err := os.WriteFile(page, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(page, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	render := func(tr *TemplateRenderer) string {
+		t.Helper()
+		var sb strings.Builder
+		if err := tr.Execute(&sb, "index.html", nil); err != nil {
+			t.Fatal(err)
+		}
+		return sb.String()
+	}
+
+	now := time.Now()
+	write("v1", now)
+	dev, err := NewFS(os.DirFS(dir), WithDevMode(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prod, err := NewFS(os.DirFS(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write("v2", now.Add(time.Second))
+	if got := render(dev); got != "v2" {
+		t.Fatalf("dev mode should reload, got %q", got)
+	}
+	if got := render(prod); got != "v1" {
+		t.Fatalf("prod mode should keep parsed templates, got %q", got)
+	}
+}
@@ -0,0 +1,273 @@
+package render
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+var errStaticNotFound = errors.New("not found")
+
+// StaticConfig 配置 StaticFS。
+type StaticConfig struct {
+	// Root 是静态文件根目录，可以是 embed.FS、os.DirFS 或 fs.Sub 的结果
+	Root fs.FS
+	// StripPrefix 在查找文件前从请求路径中去掉，如 "/static"
+	StripPrefix string
+	// Index 是目录默认文件，默认 "index.html"
+	Index string
+	// Browse 为 true 时没有默认文件的目录会列出内容，默认返回 404
+	Browse bool
+	// AllowDotfiles 为 true 时才提供以 . 开头的文件或目录（如 .env、.git/），默认返回 404 且不出现在目录列表中
+	AllowDotfiles bool
+	// SPA 为 true 时，不带扩展名且不存在的路径回退到根目录的 Index，交给前端路由处理
+	SPA bool
+	// Precompressed 为 true 时，客户端接受 gzip 且存在同名 .gz 文件则直接发送压缩版本
+	Precompressed bool
+	// CacheControl 按扩展名（含点，如 ".js"）设置 Cache-Control，空字符串键作为默认值
+	CacheControl map[string]string
+}
+
+// StaticFS 返回生产可用的静态文件 HandlerFunc：基于内容哈希的强 ETag、
+// 条件请求与 Range 由 http.ServeContent 处理，只接受 GET 与 HEAD。
+// 访问子目录时路径不以 / 结尾会 301 到带 / 的地址，保证页面里的相对链接按目录解析。
+//
+//	r.GET("/static/*filepath", render.StaticFS(render.StaticConfig{Root: assets, StripPrefix: "/static"}))
+func StaticFS(cfg StaticConfig) tinygee.HandlerFunc {
+	if cfg.Root == nil {
+		panic("render: StaticConfig.Root is nil")
+	}
+	if cfg.Index == "" {
+		cfg.Index = "index.html"
+	}
+	s := &staticServer{cfg: cfg}
+	return s.serve
+}
+
+type staticServer struct {
+	cfg   StaticConfig
+	etags sync.Map // name -> etagEntry
+}
+
+type etagEntry struct {
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+func (s *staticServer) serve(c *tinygee.Context) {
+	if c.Method != http.MethodGet && c.Method != http.MethodHead {
+		c.SetHeader("Allow", "GET, HEAD")
+		c.Status(http.StatusMethodNotAllowed)
+		return
+	}
+	p := strings.TrimPrefix(c.Path, s.cfg.StripPrefix)
+	name := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if name == "" {
+		name = "."
+	}
+	if !s.cfg.AllowDotfiles && hasDotSegment(name) {
+		s.notFound(c, fs.ErrNotExist)
+		return
+	}
+
+	fi, err := fs.Stat(s.cfg.Root, name)
+	if err == nil && fi.IsDir() {
+		index := path.Join(name, s.cfg.Index)
+		ifi, ierr := fs.Stat(s.cfg.Root, index)
+		hasIndex := ierr == nil && !ifi.IsDir()
+		// 挂载根目录（如 /static）除外：*filepath 不匹配空路径，/static/ 本身命中不了路由
+		if (hasIndex || s.cfg.Browse) && name != "." && !strings.HasSuffix(c.Req.URL.Path, "/") {
+			s.redirectDir(c)
+			return
+		}
+		if hasIndex {
+			name, fi = index, ifi
+		} else if s.cfg.Browse {
+			s.list(c, name)
+			return
+		} else {
+			err = fs.ErrNotExist
+		}
+	}
+	if err != nil {
+		if !s.cfg.SPA || path.Ext(name) != "" {
+			s.notFound(c, err)
+			return
+		}
+		name = s.cfg.Index
+		if fi, err = fs.Stat(s.cfg.Root, name); err != nil {
+			s.notFound(c, err)
+			return
+		}
+	}
+	s.serveFile(c, name, fi)
+}
+
+// redirectDir 与 http.FileServer 一样用相对地址补上末尾的 /，不依赖挂载前缀，保留查询串。
+func (s *staticServer) redirectDir(c *tinygee.Context) {
+	u := url.URL{Path: path.Base(c.Req.URL.Path) + "/", RawQuery: c.Req.URL.RawQuery}
+	c.SetHeader("Location", u.String())
+	c.AbortWithStatus(http.StatusMovedPermanently)
+}
+
+// hasDotSegment 判断清理后的路径中是否有以 . 开头的段。
+func hasDotSegment(name string) bool {
+	for seg := range strings.SplitSeq(name, "/") {
+		if seg != "." && strings.HasPrefix(seg, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *staticServer) notFound(c *tinygee.Context, err error) {
+	if errors.Is(err, fs.ErrNotExist) {
+		c.AbortWithError(http.StatusNotFound, errStaticNotFound).SetType(tinygee.ErrorTypePublic)
+		return
+	}
+	c.AbortWithError(http.StatusInternalServerError, err)
+}
+
+// serveFile 发送 name 对应的文件；Content-Type 与缓存策略始终按原文件名决定。
+func (s *staticServer) serveFile(c *tinygee.Context, name string, fi fs.FileInfo) {
+	if cc := s.cacheControl(name); cc != "" {
+		c.SetHeader("Cache-Control", cc)
+	}
+	send, sendInfo := name, fi
+	if s.cfg.Precompressed {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if acceptsGzip(c.Req.Header.Get("Accept-Encoding")) {
+			if gfi, err := fs.Stat(s.cfg.Root, name+".gz"); err == nil && !gfi.IsDir() {
+				send, sendInfo = name+".gz", gfi
+				c.SetHeader("Content-Encoding", "gzip")
+			}
+		}
+	}
+
+	f, err := s.cfg.Root.Open(send)
+	if err != nil {
+		s.notFound(c, err)
+		return
+	}
+	defer f.Close()
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		content = bytes.NewReader(b)
+	}
+	etag, err := s.etag(send, sendInfo, content)
+	if err != nil {
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	c.SetHeader("ETag", etag)
+	// ServeContent 根据 name 的扩展名推断类型，这里传原文件名而不是 .gz
+	http.ServeContent(c.Writer, c.Req, path.Base(name), sendInfo.ModTime(), content)
+}
+
+// etag 返回内容 SHA-256 前 16 字节组成的强 ETag，按大小与修改时间缓存，文件变化后重新计算。
+func (s *staticServer) etag(name string, fi fs.FileInfo, r io.ReadSeeker) (string, error) {
+	if v, ok := s.etags.Load(name); ok {
+		e := v.(etagEntry)
+		if e.size == fi.Size() && e.modTime.Equal(fi.ModTime()) {
+			return e.etag, nil
+		}
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	s.etags.Store(name, etagEntry{size: fi.Size(), modTime: fi.ModTime(), etag: etag})
+	return etag, nil
+}
+
+func (s *staticServer) cacheControl(name string) string {
+	if cc, ok := s.cfg.CacheControl[path.Ext(name)]; ok {
+		return cc
+	}
+	return s.cfg.CacheControl[""]
+}
+
+// list 输出简单的目录列表，仅在 Browse 为 true 时使用。
+func (s *staticServer) list(c *tinygee.Context, dir string) {
+	entries, err := fs.ReadDir(s.cfg.Root, dir)
+	if err != nil {
+		s.notFound(c, err)
+		return
+	}
+	base := c.Path
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	var buf bytes.Buffer
+	buf.WriteString("<!doctype html>\n<pre>\n")
+	for _, e := range entries {
+		n := e.Name()
+		if !s.cfg.AllowDotfiles && strings.HasPrefix(n, ".") {
+			continue
+		}
+		if e.IsDir() {
+			n += "/"
+		}
+		u := url.URL{Path: base + n}
+		fmt.Fprintf(&buf, "<a href=\"%s\">%s</a>\n", html.EscapeString(u.String()), html.EscapeString(n))
+	}
+	buf.WriteString("</pre>\n")
+	c.SetHeader("Content-Type", "text/html; charset=utf-8")
+	c.Data(http.StatusOK, buf.Bytes())
+}
+
+// acceptsGzip 判断 Accept-Encoding 是否接受 gzip（q=0 视为拒绝）。
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.TrimSpace(coding)
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Static 返回一个处理静态文件的 HandlerFunc，基于 http.FileServer，会列出目录。
+// 需要缓存策略、预压缩或 embed.FS 时使用 StaticFS。
+func Static(relative string, root http.FileSystem) tinygee.HandlerFunc {
+	fileServer := http.StripPrefix(relative, http.FileServer(root))
+	return func(c *tinygee.Context) {
+		// 简单安全防护：仅允许 GET
+		if c.Method != http.MethodGet {
+			c.Status(http.StatusMethodNotAllowed)
+			return
+		}
+		fileServer.ServeHTTP(c.Writer, c.Req)
+	}
+}
@@ -0,0 +1,154 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+var staticFS = fstest.MapFS{
+	"index.html":      {Data: []byte("<h1>app</h1>")},
+	"app.js":          {Data: []byte("console.log('plain')")},
+	"app.js.gz":       {Data: []byte("gzipped-bytes")},
+	"docs/guide.txt":  {Data: []byte("0123456789")},
+	"docs/.draft.txt": {Data: []byte("draft")},
+	"site/index.html": {Data: []byte("<h1>site</h1>")},
+	".env":            {Data: []byte("SECRET=1")},
+	".git/config":     {Data: []byte("[core]")},
+}
+
+func newStaticEngine(cfg StaticConfig) *tinygee.Engine {
+	cfg.Root = staticFS
+	cfg.StripPrefix = "/assets"
+	r := tinygee.New()
+	h := StaticFS(cfg)
+	r.Any("/assets", h)
+	r.Any("/assets/*filepath", h)
+	return r
+}
+
+func doStatic(r *tinygee.Engine, method, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestStaticFSETagAndRange(t *testing.T) {
+	r := newStaticEngine(StaticConfig{CacheControl: map[string]string{
+		".js": "public, max-age=31536000, immutable",
+		"":    "no-cache",
+	}})
+
+	w := doStatic(r, http.MethodGet, "/assets/app.js", nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != "console.log('plain')" {
+		t.Fatalf("status %d body %q", w.Code, w.Body.String())
+	}
+	if len(etag) != 34 || !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("want strong etag, got %q", etag)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=31536000, immutable" {
+		t.Fatalf("cache-control %q", got)
+	}
+	if got := w.Header().Get("Content-Type"); !strings.Contains(got, "javascript") {
+		t.Fatalf("content-type %q", got)
+	}
+
+	w = doStatic(r, http.MethodGet, "/assets/app.js", map[string]string{"If-None-Match": etag})
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("if-none-match: status %d", w.Code)
+	}
+
+	w = doStatic(r, http.MethodGet, "/assets/docs/guide.txt", map[string]string{"Range": "bytes=2-4"})
+	if w.Code != http.StatusPartialContent || w.Body.String() != "234" {
+		t.Fatalf("range: status %d body %q", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Fatalf("default cache-control %q", got)
+	}
+
+	w = doStatic(r, http.MethodHead, "/assets/docs/guide.txt", nil)
+	if w.Code != http.StatusOK || w.Body.Len() != 0 || w.Header().Get("Content-Length") != "10" {
+		t.Fatalf("head: status %d len %d headers %v", w.Code, w.Body.Len(), w.Header())
+	}
+
+	w = doStatic(r, http.MethodPost, "/assets/app.js", nil)
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, HEAD" {
+		t.Fatalf("post: status %d allow %q", w.Code, w.Header().Get("Allow"))
+	}
+}
+
+func TestStaticFSPrecompressed(t *testing.T) {
+	r := newStaticEngine(StaticConfig{Precompressed: true})
+
+	w := doStatic(r, http.MethodGet, "/assets/app.js", map[string]string{"Accept-Encoding": "br, gzip;q=0.8"})
+	if w.Body.String() != "gzipped-bytes" || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("want gz sibling, got %q %v", w.Body.String(), w.Header())
+	}
+	if got := w.Header().Get("Content-Type"); !strings.Contains(got, "javascript") {
+		t.Fatalf("content-type should follow original name, got %q", got)
+	}
+	if w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("missing Vary: %v", w.Header())
+	}
+
+	w = doStatic(r, http.MethodGet, "/assets/app.js", map[string]string{"Accept-Encoding": "gzip;q=0"})
+	if w.Body.String() != "console.log('plain')" || w.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("gzip refused, got %q %v", w.Body.String(), w.Header())
+	}
+}
+
+func TestStaticFSDirectoriesAndSPA(t *testing.T) {
+	r := newStaticEngine(StaticConfig{})
+	if w := doStatic(r, http.MethodGet, "/assets/docs/", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("listing should be disabled, status %d body %s", w.Code, w.Body.String())
+	}
+	if w := doStatic(r, http.MethodGet, "/assets/../../etc/passwd", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("traversal: status %d", w.Code)
+	}
+	if w := doStatic(r, http.MethodGet, "/assets", nil); w.Body.String() != "<h1>app</h1>" {
+		t.Fatalf("index: %d %q", w.Code, w.Body.String())
+	}
+	// 子目录缺少末尾的 / 时重定向，页面中的相对链接才能按目录解析
+	if w := doStatic(r, http.MethodGet, "/assets/site?v=1", nil); w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "site/?v=1" {
+		t.Fatalf("dir redirect: %d %q", w.Code, w.Header().Get("Location"))
+	}
+	if w := doStatic(r, http.MethodGet, "/assets/site/", nil); w.Body.String() != "<h1>site</h1>" {
+		t.Fatalf("sub index: %d %q", w.Code, w.Body.String())
+	}
+	for _, target := range []string{"/assets/.env", "/assets/.git/config", "/assets/docs/.draft.txt"} {
+		if w := doStatic(r, http.MethodGet, target, nil); w.Code != http.StatusNotFound {
+			t.Fatalf("dotfile %s: status %d", target, w.Code)
+		}
+	}
+
+	r = newStaticEngine(StaticConfig{Browse: true})
+	if w := doStatic(r, http.MethodGet, "/assets/docs", nil); w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "docs/" {
+		t.Fatalf("listing redirect: %d %q", w.Code, w.Header().Get("Location"))
+	}
+	w := doStatic(r, http.MethodGet, "/assets/docs/", nil)
+	if !strings.Contains(w.Body.String(), `href="/assets/docs/guide.txt"`) || strings.Contains(w.Body.String(), ".draft") {
+		t.Fatalf("listing: %d %s", w.Code, w.Body.String())
+	}
+
+	r = newStaticEngine(StaticConfig{AllowDotfiles: true})
+	if w := doStatic(r, http.MethodGet, "/assets/.env", nil); w.Code != http.StatusOK || w.Body.String() != "SECRET=1" {
+		t.Fatalf("allowed dotfile: %d %q", w.Code, w.Body.String())
+	}
+
+	r = newStaticEngine(StaticConfig{SPA: true})
+	if w := doStatic(r, http.MethodGet, "/assets/users/42", nil); w.Code != http.StatusOK || w.Body.String() != "<h1>app</h1>" {
+		t.Fatalf("spa fallback: %d %q", w.Code, w.Body.String())
+	}
+	if w := doStatic(r, http.MethodGet, "/assets/missing.js", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("missing asset should 404, got %d", w.Code)
+	}
+}
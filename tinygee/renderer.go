@@ -0,0 +1,249 @@
+package tinygee
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// 常用的响应 MIME 类型。
+const (
+	MIMEJSON     = "application/json"
+	MIMEXML      = "application/xml"
+	MIMEXML2     = "text/xml"
+	MIMEYAML     = "application/yaml"
+	MIMEYAML2    = "application/x-yaml"
+	MIMEPROTOBUF = "application/x-protobuf"
+	MIMEJS       = "application/javascript"
+	MIMEPlain    = "text/plain"
+	MIMEHTML     = "text/html"
+)
+
+// jsonpCallback 限制 JSONP 回调名只能是合法的 JS 标识符（可带 . 访问），防止注入脚本。
+var jsonpCallback = regexp.MustCompile(`^[A-Za-z_$][0-9A-Za-z_$.]*$`)
+
+// render 先完整编码再写出：编码失败时响应头尚未发送，可以改为返回 500，而不是输出半截数据。
+func (c *Context) render(code int, contentType string, body []byte, err error) {
+	if err != nil {
+		c.renderError(err)
+		return
+	}
+	c.SetHeader("Content-Type", contentType)
+	c.Status(code)
+	_, _ = c.Writer.Write(body)
+}
+
+// renderError 是编码错误的统一出口：记录为 ErrorTypeRender，
+// 响应尚未写出时改为 500 并交给 ErrorHandler。
+func (c *Context) renderError(err error) {
+	c.Error(err).SetType(ErrorTypeRender)
+	if c.engine != nil && c.engine.OnRenderError != nil {
+		c.engine.OnRenderError(c, err)
+	} else {
+		log.Printf("[tinygee] render %s %s: %v", c.Method, c.Path, err)
+	}
+	if !c.Writer.Written() {
+		c.Abort()
+		c.Status(http.StatusInternalServerError)
+		c.handleErrors()
+	}
+}
+
+func encodeJSON(obj any, escapeHTML bool, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(escapeHTML)
+	if indent != "" {
+		enc.SetIndent("", indent)
+	}
+	if err := enc.Encode(obj); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// IndentedJSON 返回带缩进的 JSON，便于调试阅读。
+func (c *Context) IndentedJSON(code int, obj any) {
+	body, err := encodeJSON(obj, true, "    ")
+	c.render(code, MIMEJSON+"; charset=utf-8", body, err)
+}
+
+// PureJSON 返回不转义 <、>、& 的 JSON。
+func (c *Context) PureJSON(code int, obj any) {
+	body, err := encodeJSON(obj, false, "")
+	c.render(code, MIMEJSON+"; charset=utf-8", body, err)
+}
+
+// SecureJSON 在顶层为数组的 JSON 前加上 Engine.SecureJSONPrefix，防止 JSON 劫持。
+func (c *Context) SecureJSON(code int, obj any) {
+	body, err := encodeJSON(obj, true, "")
+	if err == nil && bytes.HasPrefix(body, []byte("[")) {
+		prefix := "while(1);"
+		if c.engine != nil {
+			prefix = c.engine.SecureJSONPrefix
+		}
+		body = append([]byte(prefix), body...)
+	}
+	c.render(code, MIMEJSON+"; charset=utf-8", body, err)
+}
+
+// JSONP 读取查询参数 callback 包装 JSON；callback 缺失或不合法时退化为普通 JSON。
+// 输出以 /**/ 开头并带 X-Content-Type-Options: nosniff，防止被当作 Flash 等其他内容类型解析（Rosetta Flash）。
+func (c *Context) JSONP(code int, obj any) {
+	callback := c.Query("callback")
+	if callback == "" || !jsonpCallback.MatchString(callback) {
+		c.JSON(code, obj)
+		return
+	}
+	body, err := encodeJSON(obj, true, "")
+	if err == nil {
+		body = append(append([]byte("/**/"+callback+"("), bytes.TrimRight(body, "\n")...), ");"...)
+	}
+	c.Writer.Header().Set("X-Content-Type-Options", "nosniff")
+	c.render(code, MIMEJS+"; charset=utf-8", body, err)
+}
+
+// XML 返回 XML。
+func (c *Context) XML(code int, obj any) {
+	body, err := xml.Marshal(obj)
+	c.render(code, MIMEXML+"; charset=utf-8", body, err)
+}
+
+// YAML 返回 YAML。
+func (c *Context) YAML(code int, obj any) {
+	body, err := yaml.Marshal(obj)
+	c.render(code, MIMEYAML+"; charset=utf-8", body, err)
+}
+
+// ProtoBuf 返回 protobuf 二进制，obj 必须实现 proto.Message。
+func (c *Context) ProtoBuf(code int, obj any) {
+	msg, ok := obj.(proto.Message)
+	if !ok {
+		c.renderError(fmt.Errorf("tinygee: %T does not implement proto.Message", obj))
+		return
+	}
+	body, err := proto.Marshal(msg)
+	c.render(code, MIMEPROTOBUF, body, err)
+}
+
+// Negotiate 按 Accept 头在 offered 中选择响应格式；offered 为空时提供全部内置格式（含 text/xml、
+// application/x-yaml 别名），其中 protobuf 只在 data 实现 proto.Message 时提供。没有可接受的格式时返回 406。
+func (c *Context) Negotiate(code int, data any, offered ...string) {
+	if len(offered) == 0 {
+		offered = []string{MIMEJSON, MIMEXML, MIMEYAML, MIMEPlain}
+		if _, ok := data.(proto.Message); ok {
+			offered = []string{MIMEJSON, MIMEXML, MIMEYAML, MIMEPROTOBUF, MIMEPlain}
+		}
+		// 别名放在最后，通配匹配时优先选标准类型
+		offered = append(offered, MIMEXML2, MIMEYAML2)
+	}
+	switch c.NegotiateFormat(offered...) {
+	case MIMEJSON:
+		c.JSON(code, data)
+	case MIMEXML, MIMEXML2:
+		c.XML(code, data)
+	case MIMEYAML, MIMEYAML2:
+		c.YAML(code, data)
+	case MIMEPROTOBUF:
+		c.ProtoBuf(code, data)
+	case MIMEPlain:
+		c.String(code, "%v", data)
+	case "":
+		c.AbortWithStatusJSON(http.StatusNotAcceptable, map[string]any{
+			"error":   "not acceptable",
+			"offered": offered,
+		})
+	default:
+		c.renderError(errors.New("tinygee: no renderer for negotiated format"))
+	}
+}
+
+// NegotiateFormat 返回 offered 中最符合 Accept 头的一项，均不可接受时返回空串。
+// 未携带 Accept 时返回第一项。每个 offer 的 q 值取匹配它的最具体的条目（RFC 9110 §12.5.1），
+// 因此 application/json;q=0, */* 会排除 JSON；q 相同时匹配更具体的 offer 优先，再按 offered 顺序。
+func (c *Context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+	accepts := parseAccept(c.Req.Header.Get("Accept"))
+	if len(accepts) == 0 {
+		return offered[0]
+	}
+	best, bestQ, bestSpec := "", 0.0, -1
+	for _, offer := range offered {
+		q, spec := 0.0, -1
+		for _, accept := range accepts {
+			if s := accept.specificity(); s > spec && mimeMatch(accept.mime, offer) {
+				q, spec = accept.q, s
+			}
+		}
+		if q > bestQ || (q == bestQ && q > 0 && spec > bestSpec) {
+			best, bestQ, bestSpec = offer, q, spec
+		}
+	}
+	return best
+}
+
+type acceptItem struct {
+	mime string
+	q    float64
+}
+
+// specificity 区分 */*、type/* 与具体类型，数值越大越具体。
+func (a acceptItem) specificity() int {
+	switch {
+	case a.mime == "*/*":
+		return 0
+	case strings.HasSuffix(a.mime, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// parseAccept 按出现顺序解析 Accept 头；q=0 的条目保留，表示明确拒绝该类型。
+func parseAccept(header string) []acceptItem {
+	if header == "" {
+		return nil
+	}
+	var items []acceptItem
+	for _, part := range strings.Split(header, ",") {
+		mimeType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+		if mimeType == "" {
+			continue
+		}
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+			if ok && strings.TrimSpace(k) == "q" {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					q = f
+				}
+			}
+		}
+		items = append(items, acceptItem{mime: mimeType, q: q})
+	}
+	return items
+}
+
+// mimeMatch 支持 */* 与 type/* 通配。
+func mimeMatch(accept, offer string) bool {
+	if accept == "*/*" || accept == offer {
+		return true
+	}
+	if typ, ok := strings.CutSuffix(accept, "/*"); ok {
+		return strings.HasPrefix(offer, typ+"/")
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package tinygee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type renderItem struct {
+	Name string `json:"name" xml:"name" yaml:"name"`
+}
+
+func TestRenderers(t *testing.T) {
+	engine := New()
+	items := []renderItem{{Name: "<go>"}}
+	engine.GET("/indented", func(c *Context) { c.IndentedJSON(http.StatusOK, renderItem{Name: "a"}) })
+	engine.GET("/pure", func(c *Context) { c.PureJSON(http.StatusOK, items[0]) })
+	engine.GET("/secure", func(c *Context) { c.SecureJSON(http.StatusOK, items) })
+	engine.GET("/jsonp", func(c *Context) { c.JSONP(http.StatusOK, items[0]) })
+	engine.GET("/xml", func(c *Context) { c.XML(http.StatusOK, renderItem{Name: "a"}) })
+	engine.GET("/yaml", func(c *Context) { c.YAML(http.StatusOK, renderItem{Name: "a"}) })
+
+	cases := []struct {
+		path, contentType, body string
+	}{
+		{"/indented", MIMEJSON, "{\n    \"name\": \"a\"\n}\n"},
+		{"/pure", MIMEJSON, "{\"name\":\"<go>\"}\n"},
+		{"/secure", MIMEJSON, "while(1);[{\"name\":\"\\u003cgo\\u003e\"}]\n"},
+		{"/jsonp?callback=app.cb", MIMEJS, "/**/app.cb({\"name\":\"\\u003cgo\\u003e\"});"},
+		{"/jsonp?callback=alert(1)", MIMEJSON, "{\"name\":\"\\u003cgo\\u003e\"}\n"},
+		{"/xml", MIMEXML, "<renderItem><name>a</name></renderItem>"},
+		{"/yaml", MIMEYAML, "name: a\n"},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), tc.contentType) {
+			t.Fatalf("%s: content type %q", tc.path, w.Header().Get("Content-Type"))
+		}
+		if w.Body.String() != tc.body {
+			t.Fatalf("%s: want %q got %q", tc.path, tc.body, w.Body.String())
+		}
+		if tc.contentType == MIMEJS && w.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Fatalf("%s: missing nosniff header", tc.path)
+		}
+	}
+}
+
+func TestProtoBuf(t *testing.T) {
+	engine := New()
+	engine.GET("/pb", func(c *Context) { c.ProtoBuf(http.StatusOK, wrapperspb.String("hi")) })
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/pb", nil))
+	var got wrapperspb.StringValue
+	if err := proto.Unmarshal(w.Body.Bytes(), &got); err != nil || got.GetValue() != "hi" {
+		t.Fatalf("decode protobuf: %v %q", err, got.GetValue())
+	}
+}
+
+func TestRenderErrorHook(t *testing.T) {
+	engine := New()
+	var hookErr error
+	engine.OnRenderError = func(c *Context, err error) { hookErr = err }
+	engine.GET("/bad", func(c *Context) { c.JSON(http.StatusOK, map[string]any{"ch": make(chan int)}) })
+	engine.GET("/notproto", func(c *Context) { c.ProtoBuf(http.StatusOK, renderItem{}) })
+
+	for _, path := range []string{"/bad", "/notproto"} {
+		hookErr = nil
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		// 编码失败不会输出半截数据，而是交给 ErrorHandler 以私有错误渲染 500
+		if w.Code != http.StatusInternalServerError || w.Body.String() != `{"error":"internal server error"}`+"\n" {
+			t.Fatalf("%s: want 500 error body, got %d %q", path, w.Code, w.Body.String())
+		}
+		if hookErr == nil {
+			t.Fatalf("%s: hook not called", path)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	engine := New()
+	engine.GET("/item", func(c *Context) {
+		c.Negotiate(http.StatusOK, renderItem{Name: "a"}, MIMEJSON, MIMEXML, MIMEYAML)
+	})
+
+	cases := []struct {
+		accept string
+		status int
+		want   string
+	}{
+		{"", http.StatusOK, MIMEJSON},
+		{"application/xml", http.StatusOK, MIMEXML},
+		{"text/html, application/yaml;q=0.9, application/json;q=0.8", http.StatusOK, MIMEYAML},
+		{"application/*;q=0.5, application/xml", http.StatusOK, MIMEXML},
+		{"*/*", http.StatusOK, MIMEJSON},
+		{"application/json;q=0, text/html", http.StatusNotAcceptable, MIMEJSON},
+		// q 相同时具体类型优先于通配，与书写顺序无关
+		{"*/*, application/yaml", http.StatusOK, MIMEYAML},
+		{"application/*, application/xml", http.StatusOK, MIMEXML},
+		// q=0 明确拒绝该类型，通配不能把它带回来
+		{"application/json;q=0, */*", http.StatusOK, MIMEXML},
+		{"*/*;q=0.5, application/xml;q=0, application/json;q=0", http.StatusOK, MIMEYAML},
+		{"application/*;q=0, application/yaml", http.StatusOK, MIMEYAML},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/item", nil)
+		if tc.accept != "" {
+			req.Header.Set("Accept", tc.accept)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != tc.status || !strings.HasPrefix(w.Header().Get("Content-Type"), tc.want) {
+			t.Fatalf("Accept %q: got %d %q", tc.accept, w.Code, w.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestNegotiateDefaultOffers(t *testing.T) {
+	engine := New()
+	engine.GET("/item", func(c *Context) { c.Negotiate(http.StatusOK, renderItem{Name: "a"}) })
+	engine.GET("/pb", func(c *Context) { c.Negotiate(http.StatusOK, wrapperspb.String("hi")) })
+
+	// 普通结构体不提供 protobuf，只接受 protobuf 的客户端得到 406 而不是编码失败的 500
+	cases := []struct {
+		path, accept string
+		status       int
+		want         string
+	}{
+		{"/item", MIMEPROTOBUF, http.StatusNotAcceptable, MIMEJSON},
+		{"/item", MIMEPROTOBUF + ", text/plain;q=0.5", http.StatusOK, MIMEPlain},
+		{"/pb", MIMEPROTOBUF, http.StatusOK, MIMEPROTOBUF},
+		// 别名同样可以协商到，不会因为只列了标准类型而 406
+		{"/item", MIMEYAML2, http.StatusOK, MIMEYAML},
+		{"/item", MIMEXML2, http.StatusOK, MIMEXML},
+		{"/item", "*/*", http.StatusOK, MIMEJSON},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set("Accept", tc.accept)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != tc.status || !strings.HasPrefix(w.Header().Get("Content-Type"), tc.want) {
+			t.Fatalf("%s Accept %q: got %d %q", tc.path, tc.accept, w.Code, w.Header().Get("Content-Type"))
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package tinygee
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+)
+
+// noWritten 表示响应头尚未发送。
+const noWritten = -1
+
+// ResponseWriter 在 http.ResponseWriter 之上记录状态码、写出字节数与是否已发送响应头，
+// 并透传 Flusher / Hijacker / Pusher，使中间件能看到准确的响应信息。
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	http.Hijacker
+	http.Pusher
+	io.StringWriter
+
+	// Status 返回已设置的状态码，未设置时为 200。
+	Status() int
+	// Size 返回已写出的响应体字节数，未写出响应头时为 -1。
+	Size() int
+	// Written 判断响应头是否已经发送。
+	Written() bool
+	// WriteHeaderNow 立即发送响应头，适用于没有响应体的场景。
+	WriteHeaderNow()
+	// Unwrap 返回底层的 http.ResponseWriter，供 http.ResponseController 使用。
+	Unwrap() http.ResponseWriter
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	size   int
+	status int
+}
+
+var _ ResponseWriter = (*responseWriter)(nil)
+
+func (w *responseWriter) reset(writer http.ResponseWriter) {
+	w.ResponseWriter = writer
+	w.size = noWritten
+	w.status = http.StatusOK
+}
+
+// WriteHeader 只记录状态码，真正发送推迟到第一次写响应体或 WriteHeaderNow；
+// 响应头发出后再调用会被忽略，避免 superfluous WriteHeader 警告。
+func (w *responseWriter) WriteHeader(code int) {
+	if code > 0 && !w.Written() {
+		w.status = code
+	}
+}
+
+func (w *responseWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.size = 0
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.ResponseWriter.Write(data)
+	w.size += n
+	return n, err
+}
+
+func (w *responseWriter) WriteString(s string) (int, error) {
+	w.WriteHeaderNow()
+	n, err := io.WriteString(w.ResponseWriter, s)
+	w.size += n
+	return n, err
+}
+
+func (w *responseWriter) Status() int { return w.status }
+
+func (w *responseWriter) Size() int { return w.size }
+
+func (w *responseWriter) Written() bool { return w.size != noWritten }
+
+func (w *responseWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// Flush 发送响应头并把缓冲数据推给客户端。
+func (w *responseWriter) Flush() {
+	w.WriteHeaderNow()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack 接管底层连接；之后 tinygee 不会再写入响应头。
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("tinygee: response writer does not support hijacking")
+	}
+	if w.size < 0 {
+		w.size = 0
+	}
+	return hj.Hijack()
+}
+
+// Push 透传 HTTP/2 Server Push，不支持时返回 http.ErrNotSupported。
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
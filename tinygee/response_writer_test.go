@@ -0,0 +1,78 @@
+package tinygee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriterTracksRawHandler(t *testing.T) {
+	engine := New()
+	var status, legacy, size int
+	engine.Use(func(c *Context) {
+		c.Next()
+		status, size = c.Writer.Status(), c.Writer.Size()
+		legacy = c.StatusCode()
+	})
+	// 绕过 c.Status，直接使用标准库 handler 写响应
+	engine.GET("/raw", func(c *Context) {
+		http.Error(c.Writer, "teapot", http.StatusTeapot)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/raw", nil))
+	if w.Code != http.StatusTeapot || status != http.StatusTeapot {
+		t.Fatalf("want 418, recorder=%d middleware=%d", w.Code, status)
+	}
+	if legacy != status {
+		t.Fatalf("deprecated StatusCode() = %d, want %d", legacy, status)
+	}
+	if size != len("teapot\n") {
+		t.Fatalf("unexpected size %d", size)
+	}
+}
+
+func TestWriterIgnoresLateWriteHeader(t *testing.T) {
+	engine := New()
+	engine.GET("/twice", func(c *Context) {
+		c.String(http.StatusCreated, "created")
+		c.Status(http.StatusInternalServerError)
+	})
+	engine.GET("/status-only", func(c *Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/twice", nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("late WriteHeader should be ignored, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status-only", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status without body should still be sent, got %d", w.Code)
+	}
+}
+
+func TestWriterPassthrough(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := NewContext(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if c.Writer.Written() || c.Writer.Size() != -1 {
+		t.Fatalf("fresh writer should not be written")
+	}
+
+	c.Writer.Flush()
+	if !rec.Flushed || !c.Writer.Written() {
+		t.Fatalf("Flush should reach the underlying writer")
+	}
+	if _, _, err := c.Writer.Hijack(); err == nil {
+		t.Fatalf("recorder cannot be hijacked")
+	}
+	if err := c.Writer.Push("/app.js", nil); err != http.ErrNotSupported {
+		t.Fatalf("want ErrNotSupported, got %v", err)
+	}
+	if c.Writer.Unwrap() != rec {
+		t.Fatalf("Unwrap should return the original writer")
+	}
+}
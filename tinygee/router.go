@@ -1,85 +1,255 @@
 package tinygee
 
 import (
+	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 )
 
 // HandlerFunc 定义业务处理函数。
 type HandlerFunc func(*Context)
 
+// route 是一条注册好的路由。
+// paramNames 在注册时从 pattern 中解析，匹配时只需按顺序对应 values，无需重新解析。
+type route struct {
+	method     string
+	pattern    string
+	name       string // 通过 Route.Name 设置，供 Engine.URL 反查
+	paramNames []string
+	group      *RouterGroup  // 注册该路由的分组，决定使用哪些分组中间件
+	own        []HandlerFunc // 注册时传入的路由级中间件与最终 handler
+	handlers   []HandlerFunc // 全局/分组中间件 + own，注册时预先拼好
+}
+
 type router struct {
-	handlers map[string]HandlerFunc
-	roots    map[string]*node // method -> trie root
+	host   string           // 虚拟主机，默认路由树为空
+	roots  map[string]*node // method -> radix tree root
+	routes []*route         // 按注册顺序保存，便于重建中间件链
 }
 
 func newRouter() *router {
 	return &router{
-		handlers: make(map[string]HandlerFunc),
-		roots:    make(map[string]*node),
+		roots: make(map[string]*node),
 	}
 }
 
-// key 形如 GET-/path
-func routeKey(method, pattern string) string {
-	return method + "-" + pattern
+// anyMethods 是 Any 注册时覆盖的方法集合，同时决定 Allow 头中方法的排列顺序。
+var anyMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace,
 }
 
-func (r *router) addRoute(method, pattern string, handler HandlerFunc) {
+func (r *router) addRoute(method, pattern string, handlers ...HandlerFunc) *route {
+	if method == "" {
+		panic("tinygee: HTTP method must not be empty")
+	}
+	if len(handlers) == 0 {
+		panic("tinygee: no handler for " + method + " " + pattern)
+	}
+	for _, h := range handlers {
+		if h == nil {
+			panic("tinygee: handler must not be nil for " + method + " " + pattern)
+		}
+	}
+	validatePattern(pattern)
 	parts := parsePattern(pattern)
-	key := routeKey(method, pattern)
 
-	// 构建 trie
+	rt := &route{
+		method:  method,
+		pattern: "/" + strings.Join(parts, "/"),
+		own:     append([]HandlerFunc(nil), handlers...),
+	}
+	for _, part := range parts {
+		switch part[0] {
+		case ':':
+			name, _ := splitParam(part)
+			rt.paramNames = append(rt.paramNames, name)
+		case '*':
+			rt.paramNames = append(rt.paramNames, part[1:])
+		}
+	}
+	rt.handlers = rt.own
+
+	// 构建 radix tree
 	root, ok := r.roots[method]
 	if !ok {
 		root = &node{}
 		r.roots[method] = root
 	}
-	root.insert(pattern, parts, 0)
-	r.handlers[key] = handler
+	root.insert(splitPattern(parts), rt)
+	r.routes = append(r.routes, rt)
+	return rt
 }
 
-// getRoute 返回匹配到的节点和解析出的参数
-func (r *router) getRoute(method, path string) (*node, map[string]string) {
-	searchParts := parsePattern(path)
-	params := make(map[string]string)
-
+// lookup 查找路由，通配段的值追加到 values，不分配内存。
+func (r *router) lookup(method, path string, values *[]string) *route {
 	root, ok := r.roots[method]
 	if !ok {
-		return nil, nil
+		return nil
 	}
+	return root.match(path, values)
+}
 
-	n := root.search(searchParts, 0)
-	if n != nil {
-		parts := parsePattern(n.pattern)
-		for idx, part := range parts {
-			if strings.HasPrefix(part, ":") {
-				params[part[1:]] = searchParts[idx]
-			}
-			if strings.HasPrefix(part, "*") && len(part) > 1 {
-				params[part[1:]] = strings.Join(searchParts[idx:], "/")
-				break
-			}
+// getRoute 返回匹配到的路由和解析出的参数（每次新建 map，热路径请用 lookup）。
+func (r *router) getRoute(method, path string) (*route, map[string]string) {
+	var values []string
+	rt := r.lookup(method, path, &values)
+	if rt == nil {
+		return nil, nil
+	}
+	params := make(map[string]string, len(rt.paramNames))
+	for i, name := range rt.paramNames {
+		if name != "" {
+			params[name] = values[i]
 		}
-		return n, params
 	}
-	return nil, nil
+	return rt, params
 }
 
 func (r *router) handle(c *Context) {
-	n, params := r.getRoute(c.Method, c.Path)
-	if n != nil {
-		c.Params = params
-		key := routeKey(c.Method, n.pattern)
-		c.handlers = append(c.handlers, r.handlers[key])
+	method := c.Method
+	path, raw, unescape := c.Path, false, false
+	if e := c.engine; e != nil && e.UseRawPath && c.Req.URL.RawPath != "" {
+		path, raw, unescape = c.Req.URL.RawPath, true, e.UnescapePathValues
+	}
+	// 未显式注册 HEAD 时复用 GET 路由，响应体由 net/http 丢弃
+	if rt := r.find(method, path, &c.paramValues); rt != nil {
+		c.setParams(rt.paramNames, unescape)
+		c.fullPath = rt.pattern
+		c.handlers = rt.handlers
+		c.Next()
+		return
+	}
+
+	if c.engine != nil && method != http.MethodConnect && path != "/" {
+		if target, ok := r.redirectPath(c.engine, method, path, &c.paramValues); ok {
+			redirectTo(c, target, raw)
+			return
+		}
+	}
+
+	// 未命中时先写好状态码，NoRoute/NoMethod 的 handler 只需关心响应体
+	noMethod := false
+	if allow := r.allowed(path, c.Method); len(allow) > 0 {
+		c.SetHeader("Allow", strings.Join(allow, ", "))
+		c.Status(http.StatusMethodNotAllowed)
+		noMethod = true
 	} else {
-		c.handlers = append(c.handlers, func(ctx *Context) {
-			ctx.JSON(http.StatusNotFound, map[string]any{"error": "not found"})
-		})
+		c.Status(http.StatusNotFound)
+	}
+	switch {
+	case c.engine == nil && noMethod:
+		c.handlers = []HandlerFunc{defaultMissHandler(errMethodNotAllowed)}
+	case c.engine == nil:
+		c.handlers = []HandlerFunc{defaultMissHandler(errNotFound)}
+	case noMethod:
+		c.handlers = c.engine.allNoMethod
+	default:
+		c.handlers = c.engine.allNoRoute
 	}
 	c.Next()
 }
 
+// defaultMissHandler 把 404/405 作为公开错误交给 ErrorHandler，保证与其他错误格式一致。
+func defaultMissHandler(err error) HandlerFunc {
+	return func(c *Context) {
+		c.Error(err).SetType(ErrorTypePublic)
+		c.Abort()
+		c.handleErrors()
+	}
+}
+
+// allowed 返回 path 在其他方法下能命中的方法列表，用于 405 的 Allow 头。
+// 返回空切片表示该路径在任何方法下都不存在。
+func (r *router) allowed(path, reqMethod string) []string {
+	var allow, values []string
+	for method := range r.roots {
+		if method == reqMethod {
+			continue
+		}
+		values = values[:0]
+		if r.lookup(method, path, &values) != nil {
+			allow = append(allow, method)
+		}
+	}
+	if len(allow) == 0 {
+		return nil
+	}
+	// GET 路由隐式支持 HEAD
+	if contains(allow, http.MethodGet) && !contains(allow, http.MethodHead) {
+		allow = append(allow, http.MethodHead)
+	}
+	sortMethods(allow)
+	return allow
+}
+
+// sortMethods 按 anyMethods 的顺序排列，未知方法按字母序排在最后。
+func sortMethods(methods []string) {
+	rank := func(m string) int {
+		for i, am := range anyMethods {
+			if am == m {
+				return i
+			}
+		}
+		return len(anyMethods)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		ri, rj := rank(methods[i]), rank(methods[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return methods[i] < methods[j]
+	})
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePattern 在注册阶段拒绝含糊的 pattern：
+// 通配段必须有名字（* 除外）、*catchall 必须是最后一段、同一路由内参数名不可重复。
+func validatePattern(pattern string) {
+	segments := strings.Split(pattern, "/")
+	seen := make(map[string]bool)
+	for i, seg := range segments {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		name := seg[1:]
+		if seg[0] == ':' {
+			if strings.IndexByte(seg, '<') >= 0 && !strings.HasSuffix(seg, ">") {
+				panic(fmt.Sprintf("tinygee: unterminated constraint %q in route %q (constraints must not contain '/')", seg, pattern))
+			}
+			name, _ = splitParam(seg)
+			if name == "" {
+				panic(fmt.Sprintf("tinygee: route %q has a parameter without a name", pattern))
+			}
+		} else if strings.ContainsAny(name, "<>") {
+			panic(fmt.Sprintf("tinygee: catch-all %q in route %q does not support constraints", seg, pattern))
+		}
+		if seg[0] == '*' {
+			for _, rest := range segments[i+1:] {
+				if rest != "" {
+					panic(fmt.Sprintf("tinygee: catch-all %q must be the last segment in route %q", seg, pattern))
+				}
+			}
+		}
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			panic(fmt.Sprintf("tinygee: duplicate parameter %q in route %q", name, pattern))
+		}
+		seen[name] = true
+	}
+}
+
 // parsePattern 将路由 pattern 按 / 拆分，保留通配符和参数段。
 func parsePattern(pattern string) []string {
 	vs := strings.Split(pattern, "/")
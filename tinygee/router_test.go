@@ -45,3 +45,147 @@ func TestGroupMiddlewareOrder(t *testing.T) {
 		t.Fatalf("unexpected status %d", w.Code)
 	}
 }
+
+func TestRoutePriority(t *testing.T) {
+	r := newRouter()
+	// 故意以低优先级在前的顺序注册，验证匹配不依赖注册顺序
+	for _, p := range []string{
+		"/users/*rest",
+		"/users/:id",
+		"/users/new",
+		"/users/:id/posts",
+		"/users/new/posts",
+		"/files/*path",
+		"/files/static/:name",
+	} {
+		r.addRoute(http.MethodGet, p, func(c *Context) {})
+	}
+
+	cases := []struct {
+		path    string
+		pattern string
+		params  map[string]string
+	}{
+		{"/users/new", "/users/new", map[string]string{}},
+		{"/users/42", "/users/:id", map[string]string{"id": "42"}},
+		{"/users/new/posts", "/users/new/posts", map[string]string{}},
+		{"/users/7/posts", "/users/:id/posts", map[string]string{"id": "7"}},
+		{"/users/7/comments", "/users/*rest", map[string]string{"rest": "7/comments"}},
+		{"/users/new/comments", "/users/*rest", map[string]string{"rest": "new/comments"}},
+		{"/files/static/app.js", "/files/static/:name", map[string]string{"name": "app.js"}},
+		{"/files/static/js/app.js", "/files/*path", map[string]string{"path": "static/js/app.js"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			n, params := r.getRoute(http.MethodGet, tc.path)
+			if n == nil || n.pattern != tc.pattern {
+				t.Fatalf("want %s, got %+v", tc.pattern, n)
+			}
+			if len(params) != len(tc.params) {
+				t.Fatalf("params mismatch %v", params)
+			}
+			for k, v := range tc.params {
+				if params[k] != v {
+					t.Fatalf("param %s: want %q got %q", k, v, params[k])
+				}
+			}
+		})
+	}
+}
+
+func TestRouteConflicts(t *testing.T) {
+	cases := []struct {
+		name     string
+		existing []string
+		pattern  string
+	}{
+		{"param name mismatch", []string{"/users/:id"}, "/users/:name"},
+		{"nested param name mismatch", []string{"/users/:id/posts"}, "/users/:uid"},
+		{"catch-all name mismatch", []string{"/files/*path"}, "/files/*name"},
+		{"duplicate route", []string{"/users/:id"}, "/users/:id"},
+		{"catch-all not last", nil, "/files/*path/meta"},
+		{"empty param name", nil, "/users/:"},
+		{"duplicate param name", nil, "/users/:id/posts/:id"},
+		{"same constraint name mismatch", []string{"/users/:id<int>"}, "/users/:uid<int>"},
+		{"duplicate param via constraint", nil, "/users/:id<int>/posts/:id"},
+		{"unterminated constraint", nil, "/users/:id<int"},
+		{"constraint with slash", nil, "/files/:name<[a-z/]+>"},
+		{"invalid regex", nil, "/users/:id<[0-9>"},
+		{"catch-all constraint", nil, "/files/*path<int>"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newRouter()
+			for _, p := range tc.existing {
+				r.addRoute(http.MethodGet, p, func(c *Context) {})
+			}
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected panic registering %s", tc.pattern)
+				}
+			}()
+			r.addRoute(http.MethodGet, tc.pattern, func(c *Context) {})
+		})
+	}
+}
+
+func TestRadixSharedPrefix(t *testing.T) {
+	r := newRouter()
+	for _, p := range []string{"/user", "/users/:id", "/user-groups", "/u", "/users/:id/avatar", "/"} {
+		r.addRoute(http.MethodGet, p, func(c *Context) {})
+	}
+	cases := map[string]string{
+		"/":               "/",
+		"/u":              "/u",
+		"/user":           "/user",
+		"/user-groups":    "/user-groups",
+		"/users/9":        "/users/:id",
+		"/users/9/avatar": "/users/:id/avatar",
+		"/users":          "",
+		"/user-group":     "",
+	}
+	for path, want := range cases {
+		rt, _ := r.getRoute(http.MethodGet, path)
+		got := ""
+		if rt != nil {
+			got = rt.pattern
+		}
+		if got != want {
+			t.Fatalf("%s: want %q got %q", path, want, got)
+		}
+	}
+}
+
+func TestPooledContextParams(t *testing.T) {
+	engine := New()
+	engine.GET("/a/:x", func(c *Context) { c.String(http.StatusOK, "%s", c.Param("x")) })
+	engine.GET("/b", func(c *Context) { c.String(http.StatusOK, "[%s]", c.Param("x")) })
+
+	for _, tc := range []struct{ path, body string }{
+		{"/a/1", "1"},
+		{"/b", "[]"},
+		{"/a/2", "2"},
+	} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Body.String() != tc.body {
+			t.Fatalf("%s: want %q got %q", tc.path, tc.body, w.Body.String())
+		}
+	}
+}
+
+func TestUseAfterRoute(t *testing.T) {
+	engine := New()
+	api := engine.Group("/api")
+	api.GET("/ping", func(c *Context) { c.String(http.StatusOK, "pong") })
+	api.Use(func(c *Context) {
+		c.SetHeader("X-Group", "api")
+		c.Next()
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+	if w.Header().Get("X-Group") != "api" {
+		t.Fatalf("group middleware registered after route not applied")
+	}
+}
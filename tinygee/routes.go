@@ -0,0 +1,161 @@
+package tinygee
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+// Route 是注册路由后返回的句柄，目前用于给路由命名。
+type Route struct {
+	engine *Engine
+	routes []*route
+}
+
+// Name 为路由命名，供 Engine.URL 反向生成路径；名字重复时 panic。
+// Any 注册的多个方法共享同一个名字。
+func (r *Route) Name(name string) *Route {
+	if name == "" || len(r.routes) == 0 {
+		panic("tinygee: route name must not be empty")
+	}
+	e := r.engine
+	if old, ok := e.namedRoutes[name]; ok && old.pattern != r.routes[0].pattern {
+		panic(fmt.Sprintf("tinygee: route name %q already used by %q", name, old.pattern))
+	}
+	if e.namedRoutes == nil {
+		e.namedRoutes = make(map[string]*route)
+	}
+	for _, rt := range r.routes {
+		rt.name = name
+	}
+	e.namedRoutes[name] = r.routes[0]
+	return r
+}
+
+// URL 按路由名生成路径，kv 依次为参数名与参数值。
+// 路由中未出现的键会作为查询串追加；缺少参数或名字不存在时返回错误。
+func (e *Engine) URL(name string, kv ...string) (string, error) {
+	rt, ok := e.namedRoutes[name]
+	if !ok {
+		return "", fmt.Errorf("tinygee: no route named %q", name)
+	}
+	if len(kv)%2 != 0 {
+		return "", fmt.Errorf("tinygee: URL(%q) needs key/value pairs", name)
+	}
+	values := make(map[string]string, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
+		values[kv[i]] = kv[i+1]
+	}
+
+	var sb strings.Builder
+	for _, part := range parsePattern(rt.pattern) {
+		sb.WriteByte('/')
+		switch part[0] {
+		case ':':
+			key, expr := splitParam(part)
+			v, ok := values[key]
+			if !ok || v == "" {
+				return "", fmt.Errorf("tinygee: URL(%q) missing param %q", name, key)
+			}
+			if c := compileConstraint(expr, rt.pattern); c != nil && !c.match(v) {
+				return "", fmt.Errorf("tinygee: URL(%q) param %q=%q does not match <%s>", name, key, v, expr)
+			}
+			sb.WriteString(url.PathEscape(v))
+			delete(values, key)
+		case '*':
+			key := part[1:]
+			v, ok := values[key]
+			if !ok || v == "" {
+				return "", fmt.Errorf("tinygee: URL(%q) missing param %q", name, key)
+			}
+			// 通配参数可以包含 /，逐段转义
+			segs := strings.Split(strings.TrimPrefix(v, "/"), "/")
+			for i, seg := range segs {
+				segs[i] = url.PathEscape(seg)
+			}
+			sb.WriteString(strings.Join(segs, "/"))
+			delete(values, key)
+		default:
+			sb.WriteString(part)
+		}
+	}
+	path := sb.String()
+	if path == "" {
+		path = "/"
+	}
+	if len(values) > 0 {
+		q := make(url.Values, len(values))
+		for k, v := range values {
+			q.Set(k, v)
+		}
+		path += "?" + q.Encode()
+	}
+	return path, nil
+}
+
+// RouteInfo 描述一条已注册的路由。
+type RouteInfo struct {
+	// Host 是 Engine.Host 注册的虚拟主机，默认路由为空
+	Host    string
+	Method  string
+	Path    string
+	Name    string
+	Handler string
+	// Middlewares 是请求经过的中间件函数名（全局、分组、路由级），按执行顺序排列，不含 Handler
+	Middlewares []string
+}
+
+// Routes 按注册顺序返回所有路由。
+func (e *Engine) Routes() []RouteInfo {
+	var infos []RouteInfo
+	for _, r := range e.routers() {
+		for _, rt := range r.routes {
+			infos = append(infos, routeInfo(r.host, rt))
+		}
+	}
+	return infos
+}
+
+func routeInfo(host string, rt *route) RouteInfo {
+	info := RouteInfo{
+		Host:    host,
+		Method:  rt.method,
+		Path:    rt.pattern,
+		Name:    rt.name,
+		Handler: nameOfFunction(rt.own[len(rt.own)-1]),
+	}
+	for _, h := range rt.handlers[:len(rt.handlers)-1] {
+		info.Middlewares = append(info.Middlewares, nameOfFunction(h))
+	}
+	return info
+}
+
+// PrintRoutes 以表格形式输出路由，中间件按执行顺序以 " > " 连接，DebugMode 下服务启动时自动调用。
+func (e *Engine) PrintRoutes(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tNAME\tHANDLER\tMIDDLEWARES")
+	for _, r := range e.Routes() {
+		name := r.Name
+		if name == "" {
+			name = "-"
+		}
+		path := r.Path
+		if r.Host != "" {
+			path = r.Host + path
+		}
+		mws := "-"
+		if len(r.Middlewares) > 0 {
+			mws = strings.Join(r.Middlewares, " > ")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Method, path, name, r.Handler, mws)
+	}
+	_ = tw.Flush()
+}
+
+func nameOfFunction(f any) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
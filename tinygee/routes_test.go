@@ -0,0 +1,94 @@
+package tinygee
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestURL(t *testing.T) {
+	r := New()
+	noop := func(c *Context) {}
+	r.GET("/", noop).Name("home")
+	r.GET("/users/:id", noop).Name("user.show")
+	r.Group("/files").GET("/*path", noop).Name("files")
+	r.Any("/ping", noop).Name("ping")
+
+	cases := []struct {
+		name string
+		kv   []string
+		want string
+	}{
+		{"home", nil, "/"},
+		{"user.show", []string{"id", "42"}, "/users/42"},
+		{"user.show", []string{"id", "a b/c"}, "/users/a%20b%2Fc"},
+		{"user.show", []string{"id", "1", "tab", "posts", "page", "2"}, "/users/1?page=2&tab=posts"},
+		{"files", []string{"path", "css/app main.css"}, "/files/css/app%20main.css"},
+		{"ping", nil, "/ping"},
+	}
+	for _, tc := range cases {
+		got, err := r.URL(tc.name, tc.kv...)
+		if err != nil || got != tc.want {
+			t.Fatalf("URL(%q, %v) = %q, %v; want %q", tc.name, tc.kv, got, err, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		kv   []string
+	}{
+		{"missing", nil},
+		{"user.show", nil},
+		{"user.show", []string{"id"}},
+	} {
+		if _, err := r.URL(tc.name, tc.kv...); err == nil {
+			t.Fatalf("URL(%q, %v) should fail", tc.name, tc.kv)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("duplicate route name should panic")
+		}
+	}()
+	r.GET("/other", noop).Name("home")
+}
+
+func globalMW(c *Context) { c.Next() }
+
+func groupMW(c *Context) { c.Next() }
+
+func showUser(c *Context) {}
+
+func TestRoutes(t *testing.T) {
+	r := New()
+	r.Use(globalMW)
+	api := r.Group("/api")
+	api.Use(groupMW)
+	api.GET("/users/:id", showUser).Name("user.show")
+	r.POST("/login", showUser)
+
+	routes := r.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("want 2 routes, got %d", len(routes))
+	}
+	got := routes[0]
+	if got.Method != "GET" || got.Path != "/api/users/:id" || got.Name != "user.show" ||
+		!strings.HasSuffix(got.Handler, ".showUser") {
+		t.Fatalf("unexpected route %+v", got)
+	}
+	if len(got.Middlewares) != 2 || !strings.HasSuffix(got.Middlewares[0], ".globalMW") ||
+		!strings.HasSuffix(got.Middlewares[1], ".groupMW") {
+		t.Fatalf("unexpected middlewares %v", got.Middlewares)
+	}
+	if len(routes[1].Middlewares) != 1 {
+		t.Fatalf("/login should only have the global middleware: %v", routes[1].Middlewares)
+	}
+
+	var buf bytes.Buffer
+	r.PrintRoutes(&buf)
+	if out := buf.String(); !strings.Contains(out, "/api/users/:id") || !strings.Contains(out, "user.show") ||
+		!strings.Contains(out, strings.Join(got.Middlewares, " > ")) {
+		t.Fatalf("unexpected table:\n%s", out)
+	}
+}
@@ -0,0 +1,154 @@
+package tinygee
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+)
+
+// OnStart 注册服务开始监听时执行的钩子，按注册顺序调用。
+func (e *Engine) OnStart(fn func()) {
+	e.serverMu.Lock()
+	e.onStart = append(e.onStart, fn)
+	e.serverMu.Unlock()
+}
+
+// OnShutdown 注册服务关闭、在途请求排空后执行的钩子，
+// 适合停止限流器清理协程、关闭连接池等后台资源。
+func (e *Engine) OnShutdown(fn func()) {
+	e.serverMu.Lock()
+	e.onShutdown = append(e.onShutdown, fn)
+	e.serverMu.Unlock()
+}
+
+// Run 在 addr 上启动 HTTP 服务；通过 Shutdown 正常关闭时返回 nil。
+func (e *Engine) Run(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return e.RunListener(ln)
+}
+
+// RunTLS 在 addr 上启动 HTTPS 服务。
+func (e *Engine) RunTLS(addr, certFile, keyFile string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return e.serve(ln, certFile, keyFile)
+}
+
+// RunListener 在已有的 listener 上提供服务，可用于 unix socket 或测试中的随机端口。
+func (e *Engine) RunListener(ln net.Listener) error {
+	return e.serve(ln, "", "")
+}
+
+// RunContext 启动 HTTP 服务，ctx 取消后停止接收新连接，
+// 并在 ShutdownTimeout 内等待在途请求完成。
+func (e *Engine) RunContext(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	// 先同步登记 server，保证 ctx 立即取消时 Shutdown 也能找到它
+	srv, err := e.startServer(ln)
+	if err != nil {
+		return err
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- e.serveOn(srv, ln, "", "") }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx := context.Background()
+	if e.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, e.ShutdownTimeout)
+		defer cancel()
+	}
+	shutdownErr := e.Shutdown(shutdownCtx)
+	if err := <-errCh; err != nil {
+		return err
+	}
+	return shutdownErr
+}
+
+// Shutdown 优雅关闭正在运行的服务：不再接收新连接，等待在途请求结束后执行 OnShutdown 钩子。
+// ctx 到期时强制返回 ctx.Err()，钩子仍会执行。
+func (e *Engine) Shutdown(ctx context.Context) error {
+	e.serverMu.Lock()
+	srv := e.server
+	e.server = nil
+	hooks := e.onShutdown
+	e.serverMu.Unlock()
+	if srv == nil {
+		return nil
+	}
+	err := srv.Shutdown(ctx)
+	for _, fn := range hooks {
+		fn()
+	}
+	return err
+}
+
+func (e *Engine) serve(ln net.Listener, certFile, keyFile string) error {
+	srv, err := e.startServer(ln)
+	if err != nil {
+		return err
+	}
+	return e.serveOn(srv, ln, certFile, keyFile)
+}
+
+// startServer 创建并登记 http.Server，随后执行 OnStart 钩子。
+func (e *Engine) startServer(ln net.Listener) (*http.Server, error) {
+	srv := &http.Server{
+		Handler:           e,
+		ReadTimeout:       e.ReadTimeout,
+		ReadHeaderTimeout: e.ReadHeaderTimeout,
+		WriteTimeout:      e.WriteTimeout,
+		IdleTimeout:       e.IdleTimeout,
+	}
+	e.serverMu.Lock()
+	if e.server != nil {
+		e.serverMu.Unlock()
+		ln.Close()
+		return nil, errors.New("tinygee: engine is already running")
+	}
+	e.server = srv
+	hooks := e.onStart
+	e.serverMu.Unlock()
+
+	if e.DebugMode {
+		log.Printf("[tinygee-debug] listening on %s", ln.Addr())
+		e.PrintRoutes(log.Writer())
+	}
+	for _, fn := range hooks {
+		fn()
+	}
+	return srv, nil
+}
+
+func (e *Engine) serveOn(srv *http.Server, ln net.Listener, certFile, keyFile string) error {
+	var err error
+	if certFile != "" || keyFile != "" {
+		err = srv.ServeTLS(ln, certFile, keyFile)
+	} else {
+		err = srv.Serve(ln)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	// 非正常退出时清理状态，允许再次启动
+	e.serverMu.Lock()
+	if e.server == srv {
+		e.server = nil
+	}
+	e.serverMu.Unlock()
+	return err
+}
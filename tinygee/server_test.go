@@ -0,0 +1,204 @@
+package tinygee
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	return ln
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	r := New()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r.GET("/slow", func(c *Context) {
+		close(started)
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+	var events []string
+	r.OnStart(func() { events = append(events, "start") })
+	r.OnShutdown(func() { events = append(events, "shutdown") })
+
+	ln := listen(t)
+	served := make(chan error, 1)
+	go func() { served <- r.RunListener(ln) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	got := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			got <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		got <- result{string(b), err}
+	}()
+	<-started
+
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- r.Shutdown(context.Background()) }()
+	select {
+	case <-shutdownDone:
+		t.Fatal("Shutdown returned before in-flight request finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+
+	if res := <-got; res.err != nil || res.body != "done" {
+		t.Fatalf("in-flight request: %q %v", res.body, res.err)
+	}
+	if err := <-shutdownDone; err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("RunListener should return nil after Shutdown, got %v", err)
+	}
+	if len(events) != 2 || events[0] != "start" || events[1] != "shutdown" {
+		t.Fatalf("unexpected hook order %v", events)
+	}
+}
+
+func TestRunContextStopsOnCancel(t *testing.T) {
+	r := New()
+	r.ShutdownTimeout = time.Second
+	stopped := make(chan struct{})
+	r.OnShutdown(func() { close(stopped) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- r.RunContext(ctx, "127.0.0.1:0") }()
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("RunContext: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunContext did not return after cancel")
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("OnShutdown hook not called")
+	}
+}
+
+func TestRunListenerTwice(t *testing.T) {
+	r := New()
+	ln := listen(t)
+	go r.RunListener(ln)
+	defer r.Shutdown(context.Background())
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		r.serverMu.Lock()
+		running := r.server != nil
+		r.serverMu.Unlock()
+		if running || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := r.RunListener(listen(t)); err == nil {
+		t.Fatal("second RunListener should fail while running")
+	}
+}
+
+func TestRunTLS(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t)
+	r := New()
+	r.GET("/ping", func(c *Context) { c.String(http.StatusOK, "pong") })
+
+	ln := listen(t)
+	addr := ln.Addr().String()
+	ln.Close()
+	served := make(chan error, 1)
+	go func() { served <- r.RunTLS(addr, certFile, keyFile) }()
+	defer func() {
+		_ = r.Shutdown(context.Background())
+		if err := <-served; err != nil {
+			t.Errorf("RunTLS: %v", err)
+		}
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec // 测试用自签证书
+	}}
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get("https://" + addr + "/ping"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("https get: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.TLS == nil || string(body) != "pong" {
+		t.Fatalf("unexpected response %q tls=%v", body, resp.TLS != nil)
+	}
+}
+
+func writeSelfSignedCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
@@ -0,0 +1,80 @@
+package tinygee
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ClientGone 在客户端断开（请求 context 被取消）时关闭。
+func (c *Context) ClientGone() <-chan struct{} {
+	return c.Req.Context().Done()
+}
+
+// SSEvent 写出一条 Server-Sent Event 并立即 flush。
+// data 为 string 或 []byte 时原样发送（按 \n、\r\n 或 \r 拆成多个 data: 字段），其余类型编码为 JSON。
+func (c *Context) SSEvent(name string, data any) {
+	if !c.Writer.Written() {
+		header := c.Writer.Header()
+		header.Set("Content-Type", "text/event-stream")
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Connection", "keep-alive")
+		// 关闭 nginx 等反向代理的响应缓冲
+		header.Set("X-Accel-Buffering", "no")
+	}
+
+	var payload string
+	switch v := data.(type) {
+	case string:
+		payload = v
+	case []byte:
+		payload = string(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			c.renderError(err)
+			return
+		}
+		payload = string(b)
+	}
+
+	var sb strings.Builder
+	if name != "" {
+		fmt.Fprintf(&sb, "event: %s\n", sanitizeSSEField(name))
+	}
+	// SSE 中 \r\n、\n 与单独的 \r 都是行结束符，统一成 \n 再拆分，避免注入额外字段
+	for _, line := range strings.Split(sseNewlines.Replace(payload), "\n") {
+		sb.WriteString("data: ")
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	sb.WriteByte('\n')
+	_, _ = c.Writer.WriteString(sb.String())
+	c.Writer.Flush()
+}
+
+var sseNewlines = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// sanitizeSSEField 去掉换行，避免事件名拆出额外字段。
+func sanitizeSSEField(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// Stream 反复调用 step 写出数据块，每次之后 flush；
+// step 返回 false 或客户端断开时结束。返回值表示是否因客户端断开而结束。
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	gone := c.ClientGone()
+	for {
+		select {
+		case <-gone:
+			return true
+		default:
+			keepOpen := step(c.Writer)
+			c.Writer.Flush()
+			if !keepOpen {
+				return false
+			}
+		}
+	}
+}
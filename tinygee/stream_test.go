@@ -0,0 +1,124 @@
+package tinygee
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEventFormat(t *testing.T) {
+	engine := New()
+	engine.GET("/events", func(c *Context) {
+		c.SSEvent("message", "line1\nline2")
+		c.SSEvent("", map[string]int{"n": 1})
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Fatalf("unexpected cache control %q", cc)
+	}
+	want := "event: message\ndata: line1\ndata: line2\n\ndata: {\"n\":1}\n\n"
+	if w.Body.String() != want {
+		t.Fatalf("want %q got %q", want, w.Body.String())
+	}
+	if !w.Flushed {
+		t.Fatalf("events should be flushed")
+	}
+}
+
+// 单独的 \r 也是 SSE 行结束符，不能借此注入 event:/data: 字段
+func TestSSEventBareCR(t *testing.T) {
+	engine := New()
+	engine.GET("/events", func(c *Context) {
+		c.SSEvent("message", "a\revent: admin\rdata: x\r\nb")
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+	want := "event: message\ndata: a\ndata: event: admin\ndata: data: x\ndata: b\n\n"
+	if w.Body.String() != want {
+		t.Fatalf("want %q got %q", want, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "\r") {
+		t.Fatalf("bare CR leaked into stream: %q", w.Body.String())
+	}
+}
+
+func TestStreamFlushesEachChunk(t *testing.T) {
+	release := make(chan struct{})
+	engine := New()
+	engine.GET("/stream", func(c *Context) {
+		n := 0
+		c.Stream(func(w io.Writer) bool {
+			n++
+			if n == 2 {
+				<-release // 第二块之前阻塞，验证第一块已经到达客户端
+			}
+			_, _ = io.WriteString(w, "chunk\n")
+			return n < 2
+		})
+	})
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/stream")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil || line != "chunk\n" {
+		t.Fatalf("first chunk not flushed: %q %v", line, err)
+	}
+	close(release)
+	rest, _ := io.ReadAll(reader)
+	if string(rest) != "chunk\n" {
+		t.Fatalf("unexpected rest %q", rest)
+	}
+}
+
+func TestStreamStopsWhenClientGone(t *testing.T) {
+	done := make(chan bool, 1)
+	engine := New()
+	engine.GET("/stream", func(c *Context) {
+		done <- c.Stream(func(w io.Writer) bool {
+			_, _ = io.WriteString(w, "tick\n")
+			time.Sleep(10 * time.Millisecond)
+			return true
+		})
+	})
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/stream", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+	if !strings.HasPrefix(line, "tick") {
+		t.Fatalf("unexpected first line %q", line)
+	}
+	cancel()
+	resp.Body.Close()
+
+	select {
+	case gone := <-done:
+		if !gone {
+			t.Fatalf("Stream should report client gone")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Stream did not stop after client disconnect")
+	}
+}
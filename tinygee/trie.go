@@ -1,69 +1,177 @@
 package tinygee
 
-// node 表示路由树的一个节点。
+import (
+	"fmt"
+	"strings"
+)
+
+// node 是压缩前缀树（radix tree）的一个节点。
+//
+// 静态节点的 path 保存压缩后的公共前缀，如 "/users/" 与 "/user-groups" 共享 "/user"；
+// 通配节点单独挂在 params / catchAll 上，path 保存 ":id"、":id<int>" 或 "*filepath"。
+// 带通配子节点的静态节点一定以 '/' 结尾，保证参数总是占据完整的一段。
 type node struct {
-	pattern  string  // 完整匹配的路由, 如 /p/:lang
-	part     string  // 路由中的一段，如 :lang
-	children []*node // 子节点
-	isWild   bool    // 是否模糊匹配，: 或 *
+	path       string           // 静态前缀或通配段
+	indices    string           // 静态子节点的首字节，与 children 一一对应
+	children   []*node          // 静态子节点
+	params     []*node          // :param 子节点，带约束的在前，无约束的最多一个且排在最后
+	catchAll   *node            // *catchall 子节点，同一位置最多一个
+	constraint *paramConstraint // 仅 :param 节点使用，nil 表示接受任意非空段
+	route      *route           // 在此结束的路由，nil 表示中间节点
 }
 
-// matchChild 找到第一个匹配的子节点
-func (n *node) matchChild(part string) *node {
-	for _, child := range n.children {
-		if child.part == part || child.isWild {
+// insert 按 splitPattern 拆好的片段把路由挂到树上，冲突或重复注册时 panic。
+func (n *node) insert(tokens []string, r *route) {
+	cur := n
+	for _, tok := range tokens {
+		switch tok[0] {
+		case ':':
+			cur = cur.addParam(tok, r.pattern)
+		case '*':
+			cur = cur.addWild(&cur.catchAll, tok, r.pattern)
+		default:
+			cur = cur.addStatic(tok)
+		}
+	}
+	if cur.route != nil {
+		panic(fmt.Sprintf("tinygee: route %q conflicts with existing route %q", r.pattern, cur.route.pattern))
+	}
+	cur.route = r
+}
+
+// addStatic 沿静态子节点下降，必要时拆分已有节点，返回 s 结束处的节点。
+func (n *node) addStatic(s string) *node {
+	for len(s) > 0 {
+		i := strings.IndexByte(n.indices, s[0])
+		if i < 0 {
+			child := &node{path: s}
+			n.indices += s[:1]
+			n.children = append(n.children, child)
 			return child
 		}
+		child := n.children[i]
+		l := commonPrefix(child.path, s)
+		if l < len(child.path) {
+			child.split(l)
+		}
+		n = child
+		s = s[l:]
 	}
-	return nil
+	return n
+}
+
+// split 在 l 处把节点拆成父子两段，原有子树全部归入后半段。
+func (n *node) split(l int) {
+	tail := *n
+	tail.path = n.path[l:]
+	*n = node{path: n.path[:l], indices: tail.path[:1], children: []*node{&tail}}
 }
 
-// matchChildren 找到所有匹配的子节点
-func (n *node) matchChildren(part string) []*node {
-	nodes := make([]*node, 0)
-	for _, child := range n.children {
-		if child.part == part || child.isWild {
-			nodes = append(nodes, child)
+// addParam 创建或复用 :param 子节点。同一位置可以挂多个约束不同的参数，
+// 约束相同（包括都无约束）但名字不同视为冲突。
+func (n *node) addParam(tok, pattern string) *node {
+	name, expr := splitParam(tok)
+	for _, p := range n.params {
+		pname, pexpr := splitParam(p.path)
+		if pexpr != expr {
+			continue
 		}
+		if pname != name {
+			panic(fmt.Sprintf("tinygee: %q in route %q conflicts with %q registered at the same position",
+				tok, pattern, p.path))
+		}
+		return p
+	}
+	child := &node{path: tok, constraint: compileConstraint(expr, pattern)}
+	last := len(n.params) - 1
+	if expr != "" && last >= 0 && n.params[last].constraint == nil {
+		// 保持无约束参数排在最后，作为所有约束都不满足时的兜底
+		n.params = append(n.params[:last], child, n.params[last])
+	} else {
+		n.params = append(n.params, child)
 	}
-	return nodes
+	return child
 }
 
-// insert 将 pattern 插入 trie
-func (n *node) insert(pattern string, parts []string, height int) {
-	if len(parts) == height {
-		n.pattern = pattern
-		return
+// addWild 在 slot 上创建或复用通配子节点；同一位置出现不同名字视为冲突。
+func (n *node) addWild(slot **node, tok, pattern string) *node {
+	if *slot == nil {
+		*slot = &node{path: tok}
+	} else if (*slot).path != tok {
+		panic(fmt.Sprintf("tinygee: %q in route %q conflicts with %q registered at the same position",
+			tok, pattern, (*slot).path))
 	}
+	return *slot
+}
 
-	part := parts[height]
-	child := n.matchChild(part)
-	if child == nil {
-		child = &node{
-			part:   part,
-			isWild: part[0] == ':' || part[0] == '*',
+// match 在剩余路径 path 上查找路由，优先级：静态段 > 带约束的 :param > :param > *catchall。
+// 约束不满足时继续尝试后面的分支，最终都不命中则返回 nil。
+// 通配段的值按出现顺序追加到 values，回溯时弹出，整个过程不分配内存。
+func (n *node) match(path string, values *[]string) *route {
+	if path == "" {
+		return n.route
+	}
+	if i := strings.IndexByte(n.indices, path[0]); i >= 0 {
+		child := n.children[i]
+		if strings.HasPrefix(path, child.path) {
+			if r := child.match(path[len(child.path):], values); r != nil {
+				return r
+			}
+		}
+	}
+	if len(n.params) > 0 {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
 		}
-		n.children = append(n.children, child)
+		if end > 0 {
+			seg := path[:end]
+			for _, p := range n.params {
+				if p.constraint != nil && !p.constraint.match(seg) {
+					continue
+				}
+				*values = append(*values, seg)
+				if r := p.match(path[end:], values); r != nil {
+					return r
+				}
+				*values = (*values)[:len(*values)-1]
+			}
+		}
+	}
+	if n.catchAll != nil {
+		*values = append(*values, path)
+		return n.catchAll.route
 	}
-	child.insert(pattern, parts, height+1)
+	return nil
 }
 
-// search 根据路径搜索匹配的节点
-func (n *node) search(parts []string, height int) *node {
-	if len(parts) == height || (len(n.part) > 0 && n.part[0] == '*') {
-		if n.pattern == "" {
-			return nil
+// splitPattern 把 parsePattern 的结果拆成静态片段与通配段交替的序列，
+// 如 [users :id posts] -> ["/users/", ":id", "/posts"]。
+func splitPattern(parts []string) []string {
+	tokens := make([]string, 0, len(parts)+1)
+	buf := "/"
+	for i, part := range parts {
+		last := i == len(parts)-1
+		if part[0] == ':' || part[0] == '*' {
+			tokens = append(tokens, buf, part)
+			buf = "/"
+			continue
+		}
+		buf += part
+		if !last {
+			buf += "/"
 		}
-		return n
 	}
+	if len(parts) == 0 || (parts[len(parts)-1][0] != ':' && parts[len(parts)-1][0] != '*') {
+		tokens = append(tokens, buf)
+	}
+	return tokens
+}
 
-	part := parts[height]
-	children := n.matchChildren(part)
-
-	for _, child := range children {
-		if result := child.search(parts, height+1); result != nil {
-			return result
-		}
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
 	}
-	return nil
+	return i
 }
@@ -0,0 +1,440 @@
+// Package websocket 基于标准库实现 RFC 6455，可直接从 *tinygee.Context 升级连接。
+//
+// 覆盖帧编解码、掩码、分片、ping/pong、关闭握手与读取上限，不依赖第三方库。
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// 消息类型，与 RFC 6455 的 opcode 一致。
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+// 常用关闭码，见 RFC 6455 7.4.1。
+const (
+	CloseNormalClosure    = 1000
+	CloseGoingAway        = 1001
+	CloseProtocolError    = 1002
+	CloseUnsupportedData  = 1003
+	CloseNoStatusReceived = 1005
+	CloseInvalidPayload   = 1007
+	ClosePolicyViolation  = 1008
+	CloseMessageTooBig    = 1009
+	CloseInternalError    = 1011
+)
+
+const (
+	maxControlPayload = 125
+	// DefaultReadLimit 是单条消息（分片合并后）的默认上限。
+	DefaultReadLimit = 1 << 20
+)
+
+var (
+	// ErrReadLimit 表示消息超过读取上限，连接已以 1009 关闭。
+	ErrReadLimit = errors.New("websocket: read limit exceeded")
+	// ErrCloseSent 表示已经发送过关闭帧，不能再写数据。
+	ErrCloseSent = errors.New("websocket: close sent")
+)
+
+// CloseError 表示收到了对端的关闭帧。
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+// protocolError 表示对端违反协议，连接会以 1002 等关闭码终止。
+type protocolError struct {
+	code int
+	msg  string
+}
+
+func (e *protocolError) Error() string { return "websocket: " + e.msg }
+
+// Conn 是一条 WebSocket 连接。
+// 读方法只能在一个 goroutine 中调用；写方法可以并发调用，内部会串行化。
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	isServer    bool
+	subprotocol string
+
+	readLimit   int64
+	readErr     error
+	pingHandler func(appData string) error
+	pongHandler func(appData string) error
+
+	wmu          sync.Mutex
+	closeSent    bool
+	fragmentSize int
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, isServer bool) *Conn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	c := &Conn{
+		conn:      conn,
+		br:        br,
+		isServer:  isServer,
+		readLimit: DefaultReadLimit,
+	}
+	c.pingHandler = c.defaultPingHandler
+	c.pongHandler = func(string) error { return nil }
+	return c
+}
+
+// defaultPingHandler 用同样的负载回复 pong；关闭握手进行中时静默忽略。
+func (c *Conn) defaultPingHandler(appData string) error {
+	err := c.WriteControl(PongMessage, []byte(appData))
+	if errors.Is(err, ErrCloseSent) {
+		return nil
+	}
+	return err
+}
+
+// Subprotocol 返回握手时协商出的子协议。
+func (c *Conn) Subprotocol() string { return c.subprotocol }
+
+// LocalAddr 返回本地地址。
+func (c *Conn) LocalAddr() net.Addr { return c.conn.LocalAddr() }
+
+// RemoteAddr 返回对端地址。
+func (c *Conn) RemoteAddr() net.Addr { return c.conn.RemoteAddr() }
+
+// SetReadLimit 设置单条消息的最大字节数，<=0 表示不限制。
+func (c *Conn) SetReadLimit(limit int64) { c.readLimit = limit }
+
+// SetWriteFragmentSize 设置写消息时单帧的最大负载，超出部分拆成多个分片，<=0 表示不分片。
+func (c *Conn) SetWriteFragmentSize(size int) {
+	c.wmu.Lock()
+	c.fragmentSize = size
+	c.wmu.Unlock()
+}
+
+// SetPingHandler 替换收到 ping 时的处理逻辑，nil 恢复为自动回复 pong。
+func (c *Conn) SetPingHandler(h func(appData string) error) {
+	if h == nil {
+		h = c.defaultPingHandler
+	}
+	c.pingHandler = h
+}
+
+// SetPongHandler 设置收到 pong 时的回调，常用于刷新读超时。
+func (c *Conn) SetPongHandler(h func(appData string) error) {
+	if h == nil {
+		h = func(string) error { return nil }
+	}
+	c.pongHandler = h
+}
+
+// SetReadDeadline 设置底层连接的读超时。
+func (c *Conn) SetReadDeadline(t time.Time) error { return c.conn.SetReadDeadline(t) }
+
+// SetWriteDeadline 设置底层连接的写超时。
+func (c *Conn) SetWriteDeadline(t time.Time) error { return c.conn.SetWriteDeadline(t) }
+
+// ReadMessage 读取一条完整消息（自动合并分片），期间收到的控制帧会被就地处理。
+// 收到对端关闭帧时回应关闭帧并返回 *CloseError。
+func (c *Conn) ReadMessage() (messageType int, p []byte, err error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, c.failRead(err)
+		}
+		switch opcode {
+		case PingMessage:
+			if err := c.pingHandler(string(payload)); err != nil {
+				return 0, nil, c.failRead(err)
+			}
+			continue
+		case PongMessage:
+			if err := c.pongHandler(string(payload)); err != nil {
+				return 0, nil, c.failRead(err)
+			}
+			continue
+		case CloseMessage:
+			return 0, nil, c.failRead(c.handleClose(payload))
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.failRead(&protocolError{CloseProtocolError, "unexpected continuation frame"})
+			}
+		default:
+			if messageType != 0 {
+				return 0, nil, c.failRead(&protocolError{CloseProtocolError, "expected continuation frame"})
+			}
+			messageType = opcode
+		}
+
+		if c.readLimit > 0 && int64(len(p)+len(payload)) > c.readLimit {
+			return 0, nil, c.failRead(ErrReadLimit)
+		}
+		p = append(p, payload...)
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(p) {
+				return 0, nil, c.failRead(&protocolError{CloseInvalidPayload, "invalid UTF-8 in text message"})
+			}
+			return messageType, p, nil
+		}
+	}
+}
+
+// readFrame 读取并解码一帧，负责长度、掩码与控制帧约束的校验。
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+	fin = head[0]&0x80 != 0
+	if head[0]&0x70 != 0 {
+		err = &protocolError{CloseProtocolError, "unexpected reserved bits"}
+		return
+	}
+	opcode = int(head[0] & 0x0f)
+	masked := head[1]&0x80 != 0
+	length := int64(head[1] & 0x7f)
+
+	switch opcode {
+	case continuationFrame, TextMessage, BinaryMessage:
+	case CloseMessage, PingMessage, PongMessage:
+		if !fin || length > maxControlPayload {
+			err = &protocolError{CloseProtocolError, "invalid control frame"}
+			return
+		}
+	default:
+		err = &protocolError{CloseProtocolError, fmt.Sprintf("unknown opcode %d", opcode)}
+		return
+	}
+	// 客户端发出的帧必须带掩码，服务端发出的帧不能带掩码
+	if masked != c.isServer {
+		err = &protocolError{CloseProtocolError, "bad mask flag"}
+		return
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		u := binary.BigEndian.Uint64(ext[:])
+		if u>>63 != 0 {
+			err = &protocolError{CloseProtocolError, "invalid payload length"}
+			return
+		}
+		length = int64(u)
+	}
+	// 在分配内存前就拒绝超限的帧
+	if c.readLimit > 0 && length > c.readLimit {
+		err = ErrReadLimit
+		return
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	if masked {
+		maskBytes(mask, payload)
+	}
+	return
+}
+
+// handleClose 解析关闭帧并回应，返回给调用方的 *CloseError。
+func (c *Conn) handleClose(payload []byte) error {
+	code, text := CloseNoStatusReceived, ""
+	switch {
+	case len(payload) == 1:
+		return &protocolError{CloseProtocolError, "invalid close payload"}
+	case len(payload) >= 2:
+		code = int(binary.BigEndian.Uint16(payload))
+		text = string(payload[2:])
+		if !validCloseCode(code) {
+			return &protocolError{CloseProtocolError, fmt.Sprintf("invalid close code %d", code)}
+		}
+		if !utf8.ValidString(text) {
+			return &protocolError{CloseInvalidPayload, "invalid UTF-8 in close reason"}
+		}
+	}
+	echo := code
+	if echo == CloseNoStatusReceived {
+		echo = CloseNormalClosure
+	}
+	_ = c.WriteClose(echo, "")
+	return &CloseError{Code: code, Text: text}
+}
+
+// failRead 记录致命读错误；协议错误与超限会先尝试发送对应的关闭帧。
+func (c *Conn) failRead(err error) error {
+	var pe *protocolError
+	switch {
+	case errors.As(err, &pe):
+		_ = c.WriteClose(pe.code, pe.msg)
+	case errors.Is(err, ErrReadLimit):
+		_ = c.WriteClose(CloseMessageTooBig, "message too big")
+	}
+	c.readErr = err
+	return err
+}
+
+// WriteMessage 写出一条文本或二进制消息，设置了分片大小时自动拆成多帧。
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", messageType)
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	opcode := messageType
+	for {
+		chunk := data
+		if c.fragmentSize > 0 && len(chunk) > c.fragmentSize {
+			chunk = data[:c.fragmentSize]
+		}
+		data = data[len(chunk):]
+		fin := len(data) == 0
+		if err := c.writeFrame(fin, opcode, chunk); err != nil {
+			return err
+		}
+		if fin {
+			return nil
+		}
+		opcode = continuationFrame
+	}
+}
+
+// WriteControl 写出 ping / pong / close 控制帧，负载不得超过 125 字节。
+func (c *Conn) WriteControl(messageType int, data []byte) error {
+	if messageType != PingMessage && messageType != PongMessage && messageType != CloseMessage {
+		return fmt.Errorf("websocket: invalid control message type %d", messageType)
+	}
+	if len(data) > maxControlPayload {
+		return errors.New("websocket: control frame payload too large")
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	if messageType == CloseMessage {
+		c.closeSent = true
+	}
+	return c.writeFrame(true, messageType, data)
+}
+
+// Ping 发送 ping 帧。
+func (c *Conn) Ping(data []byte) error { return c.WriteControl(PingMessage, data) }
+
+// WriteClose 发起（或回应）关闭握手，之后不能再写数据帧。
+func (c *Conn) WriteClose(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > maxControlPayload {
+		payload = payload[:maxControlPayload]
+	}
+	return c.WriteControl(CloseMessage, payload)
+}
+
+// Close 尽力发送 1000 关闭帧后关闭底层连接。
+// 需要等待对端确认时，先调用 WriteClose，再读到 *CloseError 后调用 Close。
+func (c *Conn) Close() error {
+	_ = c.SetWriteDeadline(time.Now().Add(time.Second))
+	_ = c.WriteClose(CloseNormalClosure, "")
+	return c.conn.Close()
+}
+
+// writeFrame 编码一帧并写出；调用方持有 wmu。客户端会用随机掩码加密负载副本。
+func (c *Conn) writeFrame(fin bool, opcode int, payload []byte) error {
+	header := make([]byte, 0, 14)
+	b0 := byte(opcode)
+	if fin {
+		b0 |= 0x80
+	}
+	header = append(header, b0)
+
+	var maskBit byte
+	if !c.isServer {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		header = append(header, maskBit|byte(n))
+	case n <= 0xffff:
+		header = append(header, maskBit|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, maskBit|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	if !c.isServer {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		header = append(header, mask[:]...)
+		masked := make([]byte, len(payload))
+		copy(masked, payload)
+		maskBytes(mask, masked)
+		payload = masked
+	}
+
+	buf := net.Buffers{header, payload}
+	_, err := buf.WriteTo(c.conn)
+	return err
+}
+
+func maskBytes(mask [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= mask[i&3]
+	}
+}
+
+// validCloseCode 依据 RFC 6455 7.4 判断关闭码是否允许出现在关闭帧里。
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
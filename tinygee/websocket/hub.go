@@ -0,0 +1,103 @@
+package websocket
+
+import "sync"
+
+// defaultSendBuffer 是每个连接待发送队列的默认长度。
+const defaultSendBuffer = 16
+
+type hubMessage struct {
+	messageType int
+	data        []byte
+}
+
+// Hub 管理一组连接并支持广播。
+// 每个连接有独立的发送队列与写 goroutine，慢客户端队列满时会被断开，不会拖住其他连接。
+type Hub struct {
+	// SendBuffer 是每个连接的发送队列长度，需在 Register 前设置，0 使用默认值
+	SendBuffer int
+
+	mu      sync.RWMutex
+	clients map[*Conn]chan hubMessage
+}
+
+// NewHub 创建空的 Hub。
+func NewHub() *Hub {
+	return &Hub{clients: make(map[*Conn]chan hubMessage)}
+}
+
+// Register 把连接加入 Hub 并启动它的写 goroutine。
+func (h *Hub) Register(c *Conn) {
+	size := h.SendBuffer
+	if size <= 0 {
+		size = defaultSendBuffer
+	}
+	ch := make(chan hubMessage, size)
+	h.mu.Lock()
+	h.clients[c] = ch
+	h.mu.Unlock()
+
+	go func() {
+		for msg := range ch {
+			if err := c.WriteMessage(msg.messageType, msg.data); err != nil {
+				h.Unregister(c)
+				c.Close()
+				// 继续消费直到 channel 被关闭，避免广播方阻塞
+			}
+		}
+	}()
+}
+
+// Unregister 把连接移出 Hub，不会关闭连接本身。
+func (h *Hub) Unregister(c *Conn) {
+	h.mu.Lock()
+	ch, ok := h.clients[c]
+	if ok {
+		delete(h.clients, c)
+		close(ch)
+	}
+	h.mu.Unlock()
+}
+
+// Broadcast 把消息投递给所有连接，返回成功入队的连接数。
+func (h *Hub) Broadcast(messageType int, data []byte) int {
+	msg := hubMessage{messageType: messageType, data: data}
+	var slow []*Conn
+	sent := 0
+
+	h.mu.RLock()
+	for c, ch := range h.clients {
+		select {
+		case ch <- msg:
+			sent++
+		default:
+			slow = append(slow, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range slow {
+		h.Unregister(c)
+		c.Close()
+	}
+	return sent
+}
+
+// Len 返回当前连接数。
+func (h *Hub) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+// Close 断开并移除所有连接。
+func (h *Hub) Close() {
+	h.mu.Lock()
+	clients := h.clients
+	h.clients = make(map[*Conn]chan hubMessage)
+	h.mu.Unlock()
+
+	for c, ch := range clients {
+		close(ch)
+		c.Close()
+	}
+}
@@ -0,0 +1,236 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+// acceptGUID 是 RFC 6455 规定的握手魔数。
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Upgrader 把 HTTP 请求升级为 WebSocket 连接。
+type Upgrader struct {
+	// ReadLimit 是单条消息的最大字节数，0 使用 DefaultReadLimit
+	ReadLimit int64
+	// Subprotocols 是服务端支持的子协议，按客户端给出的顺序选第一个匹配项
+	Subprotocols []string
+	// CheckOrigin 校验 Origin 头，nil 时要求 Origin 与 Host 一致（无 Origin 视为同源）
+	CheckOrigin func(r *http.Request) bool
+}
+
+// Upgrade 完成握手并接管连接；失败时已向客户端写出 4xx 响应并中止后续 handler。
+func (u *Upgrader) Upgrade(c *tinygee.Context) (*Conn, error) {
+	r := c.Req
+	fail := func(code int, msg string) (*Conn, error) {
+		c.AbortWithStatusJSON(code, map[string]string{"error": msg})
+		return nil, errors.New("websocket: " + msg)
+	}
+
+	if r.Method != http.MethodGet {
+		return fail(http.StatusMethodNotAllowed, "upgrade requires GET")
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return fail(http.StatusBadRequest, "missing upgrade headers")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		c.SetHeader("Sec-WebSocket-Version", "13")
+		return fail(http.StatusUpgradeRequired, "unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return fail(http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		return fail(http.StatusForbidden, "origin not allowed")
+	}
+	subprotocol := u.selectSubprotocol(r)
+
+	// 先记录 101，便于 Logger 等中间件看到真实状态
+	c.Writer.WriteHeader(http.StatusSwitchingProtocols)
+	netConn, brw, err := c.Writer.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	c.Abort()
+	// 接管的连接可能仍带着 http.Server 按 ReadTimeout/WriteTimeout 设置的截止时间（取决于 Go 版本
+	// 与 Writer 包装），显式清除，否则长连接到时即断
+	if err := netConn.SetDeadline(time.Time{}); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	sb.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n")
+	if subprotocol != "" {
+		sb.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	sb.WriteString("\r\n")
+	if _, err := netConn.Write([]byte(sb.String())); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	conn := newConn(netConn, brw.Reader, true)
+	conn.subprotocol = subprotocol
+	if u.ReadLimit > 0 {
+		conn.readLimit = u.ReadLimit
+	}
+	return conn, nil
+}
+
+func (u *Upgrader) selectSubprotocol(r *http.Request) string {
+	for _, offered := range headerTokens(r.Header, "Sec-WebSocket-Protocol") {
+		for _, supported := range u.Subprotocols {
+			if offered == supported {
+				return offered
+			}
+		}
+	}
+	return ""
+}
+
+// Dial 以客户端身份连接 ws:// 或 wss:// 地址，主要用于测试与服务间调用。
+func Dial(ctx context.Context, rawURL string, header http.Header) (*Conn, *http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	var useTLS bool
+	switch u.Scheme {
+	case "ws", "http":
+		u.Scheme = "http"
+	case "wss", "https":
+		u.Scheme, useTLS = "https", true
+	default:
+		return nil, nil, fmt.Errorf("websocket: unsupported scheme %q", u.Scheme)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		if useTLS {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	var d net.Dialer
+	netConn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, nil, err
+	}
+	if useTLS {
+		tlsConn := tls.Client(netConn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			netConn.Close()
+			return nil, nil, err
+		}
+		netConn = tlsConn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = netConn.SetDeadline(deadline)
+	}
+
+	var raw [16]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(raw[:])
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(netConn); err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+
+	br := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		netConn.Close()
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		!headerContains(resp.Header, "Upgrade", "websocket") ||
+		resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		netConn.Close()
+		return nil, resp, fmt.Errorf("websocket: bad handshake (status %d)", resp.StatusCode)
+	}
+	_ = netConn.SetDeadline(time.Time{})
+
+	conn := newConn(netConn, br, false)
+	conn.subprotocol = resp.Header.Get("Sec-WebSocket-Protocol")
+	return conn, resp, nil
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// headerTokens 拆分逗号分隔的头部值，去掉空白。
+func headerTokens(h http.Header, key string) []string {
+	var tokens []string
+	for _, v := range h.Values(key) {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	return tokens
+}
+
+func headerContains(h http.Header, key, token string) bool {
+	for _, t := range headerTokens(h, key) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
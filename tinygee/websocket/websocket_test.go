@@ -0,0 +1,289 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xrjjing/Learn4Go/tinygee"
+)
+
+// newEchoServer 启动一个把收到的消息原样返回的 tinygee 服务。
+func newEchoServer(t *testing.T, up *Upgrader, onConn func(*Conn)) *httptest.Server {
+	t.Helper()
+	app := tinygee.New()
+	app.GET("/ws", func(c *tinygee.Context) {
+		conn, err := up.Upgrade(c)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if onConn != nil {
+			onConn(conn)
+			return
+		}
+		for {
+			typ, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(typ, data); err != nil {
+				return
+			}
+		}
+	})
+	srv := httptest.NewServer(app)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func dial(t *testing.T, srv *httptest.Server, header http.Header) *Conn {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, _, err := Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", header)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	return conn
+}
+
+func TestEcho(t *testing.T) {
+	srv := newEchoServer(t, &Upgrader{}, nil)
+	conn := dial(t, srv, nil)
+
+	large := bytes.Repeat([]byte("x"), 70000) // 触发 64 位长度编码
+	for _, tc := range []struct {
+		typ  int
+		data []byte
+	}{
+		{TextMessage, []byte("hello")},
+		{BinaryMessage, []byte{0, 1, 2}},
+		{BinaryMessage, large},
+	} {
+		if err := conn.WriteMessage(tc.typ, tc.data); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		typ, data, err := conn.ReadMessage()
+		if err != nil || typ != tc.typ || !bytes.Equal(data, tc.data) {
+			t.Fatalf("echo mismatch: typ=%d len=%d err=%v", typ, len(data), err)
+		}
+	}
+}
+
+// http.Server 的 ReadTimeout/WriteTimeout 不能作用到已升级的连接上
+func TestUpgradeClearsServerDeadlines(t *testing.T) {
+	app := tinygee.New()
+	app.ReadTimeout = 50 * time.Millisecond
+	app.WriteTimeout = 50 * time.Millisecond
+	up := &Upgrader{}
+	app.GET("/ws", func(c *tinygee.Context) {
+		conn, err := up.Upgrade(c)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			typ, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(typ, data); err != nil {
+				return
+			}
+		}
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = app.RunListener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown(context.Background()) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, _, err := Dial(ctx, "ws://"+ln.Addr().String()+"/ws", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(80 * time.Millisecond) // 超过两个超时
+		if err := conn.WriteMessage(TextMessage, []byte("ping")); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+		if _, data, err := conn.ReadMessage(); err != nil || string(data) != "ping" {
+			t.Fatalf("echo %d after server timeout: %q %v", i, data, err)
+		}
+	}
+}
+
+func TestFragmentedMessage(t *testing.T) {
+	srv := newEchoServer(t, &Upgrader{}, nil)
+	conn := dial(t, srv, nil)
+	conn.SetWriteFragmentSize(3)
+
+	// 分片之间插入 ping，服务端应先回 pong 再拼出完整消息
+	pongs := 0
+	conn.SetPongHandler(func(string) error { pongs++; return nil })
+	conn.wmu.Lock()
+	_ = conn.writeFrame(false, TextMessage, []byte("hel"))
+	_ = conn.writeFrame(true, PingMessage, []byte("p"))
+	_ = conn.writeFrame(true, continuationFrame, []byte("lo"))
+	conn.wmu.Unlock()
+
+	typ, data, err := conn.ReadMessage()
+	if err != nil || typ != TextMessage || string(data) != "hello" {
+		t.Fatalf("unexpected message %d %q %v", typ, data, err)
+	}
+	if pongs != 1 {
+		t.Fatalf("want 1 pong, got %d", pongs)
+	}
+
+	if err := conn.WriteMessage(TextMessage, []byte("fragmented payload")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, data, err = conn.ReadMessage(); err != nil || string(data) != "fragmented payload" {
+		t.Fatalf("unexpected message %q %v", data, err)
+	}
+}
+
+func TestCloseHandshake(t *testing.T) {
+	got := make(chan error, 1)
+	srv := newEchoServer(t, &Upgrader{}, func(c *Conn) {
+		_, _, err := c.ReadMessage()
+		got <- err
+	})
+	conn := dial(t, srv, nil)
+
+	if err := conn.WriteClose(CloseGoingAway, "bye"); err != nil {
+		t.Fatalf("write close: %v", err)
+	}
+	if err := conn.WriteMessage(TextMessage, []byte("late")); !errors.Is(err, ErrCloseSent) {
+		t.Fatalf("want ErrCloseSent, got %v", err)
+	}
+
+	var ce *CloseError
+	if err := <-got; !errors.As(err, &ce) || ce.Code != CloseGoingAway || ce.Text != "bye" {
+		t.Fatalf("server got %v", err)
+	}
+	// 服务端应回应同样的关闭码
+	if _, _, err := conn.ReadMessage(); !errors.As(err, &ce) || ce.Code != CloseGoingAway {
+		t.Fatalf("client got %v", err)
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	got := make(chan error, 1)
+	srv := newEchoServer(t, &Upgrader{ReadLimit: 8}, func(c *Conn) {
+		_, _, err := c.ReadMessage()
+		got <- err
+	})
+	conn := dial(t, srv, nil)
+	conn.SetWriteFragmentSize(4)
+
+	_ = conn.WriteMessage(BinaryMessage, bytes.Repeat([]byte("a"), 12))
+	if err := <-got; !errors.Is(err, ErrReadLimit) {
+		t.Fatalf("want ErrReadLimit, got %v", err)
+	}
+	var ce *CloseError
+	if _, _, err := conn.ReadMessage(); !errors.As(err, &ce) || ce.Code != CloseMessageTooBig {
+		t.Fatalf("want 1009 close, got %v", err)
+	}
+}
+
+func TestUnmaskedClientFrameRejected(t *testing.T) {
+	got := make(chan error, 1)
+	srv := newEchoServer(t, &Upgrader{}, func(c *Conn) {
+		_, _, err := c.ReadMessage()
+		got <- err
+	})
+	conn := dial(t, srv, nil)
+
+	// 伪装成服务端写帧，即不加掩码
+	conn.isServer = true
+	_ = conn.WriteMessage(TextMessage, []byte("oops"))
+	conn.isServer = false
+
+	var pe *protocolError
+	if err := <-got; !errors.As(err, &pe) {
+		t.Fatalf("want protocol error, got %v", err)
+	}
+	var ce *CloseError
+	if _, _, err := conn.ReadMessage(); !errors.As(err, &ce) || ce.Code != CloseProtocolError {
+		t.Fatalf("want 1002 close, got %v", err)
+	}
+}
+
+func TestUpgradeRejections(t *testing.T) {
+	up := &Upgrader{Subprotocols: []string{"chat"}}
+	srv := newEchoServer(t, up, nil)
+
+	resp, err := http.Get(srv.URL + "/ws")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("plain GET: want 400 got %d", resp.StatusCode)
+	}
+
+	ctx := context.Background()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	_, resp, err = Dial(ctx, wsURL, http.Header{"Origin": {"http://evil.example"}})
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("cross origin should be rejected: %v", err)
+	}
+
+	conn := dial(t, srv, http.Header{"Sec-WebSocket-Protocol": {"v2, chat"}})
+	if conn.Subprotocol() != "chat" {
+		t.Fatalf("unexpected subprotocol %q", conn.Subprotocol())
+	}
+}
+
+func TestHubBroadcast(t *testing.T) {
+	hub := NewHub()
+	defer hub.Close()
+	srv := newEchoServer(t, &Upgrader{}, func(c *Conn) {
+		hub.Register(c)
+		defer hub.Unregister(c)
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	clients := []*Conn{dial(t, srv, nil), dial(t, srv, nil), dial(t, srv, nil)}
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.Len() != len(clients) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := hub.Broadcast(TextMessage, []byte("news")); n != len(clients) {
+		t.Fatalf("broadcast reached %d clients", n)
+	}
+	for i, c := range clients {
+		if _, data, err := c.ReadMessage(); err != nil || string(data) != "news" {
+			t.Fatalf("client %d got %q %v", i, data, err)
+		}
+	}
+
+	clients[0].Close()
+	deadline = time.Now().Add(2 * time.Second)
+	for hub.Len() != len(clients)-1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if hub.Len() != len(clients)-1 {
+		t.Fatalf("closed client should be unregistered, len=%d", hub.Len())
+	}
+}
@@ -0,0 +1,9 @@
+// Package web 把前端页面编译进 Go 二进制，配合 render.StaticFS 使用。
+package web
+
+import "embed"
+
+// Files 包含根目录下的页面、脚本以及 css、js 子目录。
+//
+//go:embed *.html *.js css js
+var Files embed.FS